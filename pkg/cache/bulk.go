@@ -0,0 +1,322 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+// Item is a value written by SetMany.
+type Item[T any] struct {
+	Key   string
+	Value T
+	TTL   time.Duration
+	// Tags associates the entry with tags, see SetParams.Tags.
+	Tags []string
+}
+
+// lookup is the result of reading one key in a batch.
+type lookup[T any] struct {
+	value T
+	meta  Metadata
+	err   error
+}
+
+// GetMany returns the cached values stored under keys in a single round
+// trip. Missing keys, negatively cached keys and values that cannot be
+// deserialized are absent from the result.
+func GetMany[T any](ctx context.Context, c *Client, keys ...string) (map[string]T, error) {
+	ctx, finish := c.observe(ctx, OpGetMany, strings.Join(keys, ","))
+
+	lookups, err := getMany[T](ctx, c, nil, keys)
+	if err != nil {
+		finish(OutcomeError, err)
+		return nil, err
+	}
+
+	values := make(map[string]T, len(keys))
+	for i, l := range lookups {
+		if l.err == nil {
+			values[keys[i]] = l.value
+		}
+	}
+
+	if len(values) < len(keys) {
+		finish(OutcomeMiss, nil)
+	} else {
+		finish(OutcomeHit, nil)
+	}
+	return values, nil
+}
+
+// SetMany serializes and stores every item in a single pipelined round trip,
+// each with its own TTL, after tagging them in another one.
+func SetMany[T any](ctx context.Context, c *Client, items ...Item[T]) error {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Key
+	}
+
+	ctx, finish := c.observe(ctx, OpSetMany, strings.Join(keys, ","))
+
+	entries := make([]StoreItem, len(items))
+	tags := make([]TagItem, 0, len(items))
+	for i, item := range items {
+		params := SetParams{Client: c, Key: item.Key, TTL: item.TTL}
+		data, err := encode(params, item.Value, 0)
+		if err != nil {
+			finish(OutcomeError, err)
+			return err
+		}
+		entries[i] = StoreItem{Key: item.Key, Value: data, TTL: item.TTL}
+		tags = append(tags, TagItem{Keys: []string{item.Key}, TagKeys: item.Tags, TTL: item.TTL})
+	}
+
+	if err := c.tagMany(ctx, tags); err != nil {
+		finish(OutcomeError, err)
+		return err
+	}
+
+	err := setMany(ctx, c, entries)
+	finish(outcomeOf(err), err)
+	return err
+}
+
+// Result is the value GetOrSetMany returns for one key. Found is false for
+// keys that are neither cached nor returned by the loader.
+type Result[T any] struct {
+	Value T
+	Found bool
+}
+
+// GetOrSetMany is the batch form of GetOrSet. It reads every key in a single
+// round trip and calls loader once with the keys that missed, each listed
+// once, storing what it returns with params.TTL and params.SoftTTL
+// (params.Key is ignored). The results are in the order of keys, one per
+// key including duplicates; keys that loader does not return are not Found,
+// and are negatively cached when params.NegativeTTL is set and NegativeTags
+// includes fault.NotFound (the default).
+//
+// Stale values, and values picked for early refresh when params.Beta is set,
+// are returned right away and reloaded in the background with a single
+// loader call. Unlike GetOrSet, concurrent calls missing the same keys each
+// call loader.
+//
+// Example:
+//
+//	results, err := cache.GetOrSetMany(ctx, cache.SetParams{
+//	    Client: client,
+//	    TTL:    5 * time.Minute,
+//	}, keys, func(missing []string) (map[string]User, error) {
+//	    return db.GetUsersByKeys(ctx, missing)
+//	})
+//	for i, r := range results {
+//	    if r.Found {
+//	        users[keys[i]] = r.Value
+//	    }
+//	}
+func GetOrSetMany[T any](ctx context.Context, params SetParams, keys []string, loader func(missing []string) (map[string]T, error)) ([]Result[T], error) {
+	c := params.Client
+	ctx, finish := c.observe(ctx, OpGetOrSetMany, strings.Join(keys, ","))
+
+	outcome := OutcomeHit
+	lookups, err := getMany[T](ctx, c, params.codec, keys)
+	if err != nil {
+		if c.failClosed() {
+			finish(OutcomeError, err)
+			return nil, err
+		}
+		c.log.Warn("failed to get from cache", "keys", keys, "error", err)
+		outcome = OutcomeError
+		lookups = make([]lookup[T], len(keys))
+		for i := range lookups {
+			lookups[i].err = err
+		}
+	}
+
+	now := time.Now()
+	results := make([]Result[T], len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	var missing, stale []string
+	for i, l := range lookups {
+		key := keys[i]
+		_, dup := seen[key]
+		seen[key] = struct{}{}
+
+		switch {
+		case l.meta.Negative:
+		case l.err != nil:
+			if !dup {
+				missing = append(missing, key)
+			}
+		default:
+			results[i] = Result[T]{Value: l.value, Found: true}
+			if !dup && (l.meta.Stale(now) || l.meta.refreshEarly(now, params.Beta)) {
+				stale = append(stale, key)
+			}
+		}
+	}
+
+	if len(stale) > 0 {
+		refreshMany(ctx, params, stale, loader)
+	}
+
+	if len(missing) > 0 {
+		if outcome == OutcomeHit {
+			outcome = OutcomeMiss
+		}
+
+		loaded, err := loadMany(ctx, params, missing, loader)
+		if err != nil {
+			finish(outcome, err)
+			return nil, err
+		}
+		for i, key := range keys {
+			if results[i].Found || lookups[i].meta.Negative {
+				continue
+			}
+			if value, ok := loaded[key]; ok {
+				results[i] = Result[T]{Value: value, Found: true}
+			}
+		}
+	}
+
+	finish(outcome, nil)
+	return results, nil
+}
+
+// getMany reads keys from the local tier and, for the rest, from the store
+// in a single round trip. Each lookup holds the key's value or the error Get
+// would have returned for it.
+func getMany[T any](ctx context.Context, c *Client, codec Codec, keys []string) ([]lookup[T], error) {
+	lookups := make([]lookup[T], len(keys))
+
+	var remote []int
+	for i, key := range keys {
+		data, ok := c.readLocal(key)
+		if !ok {
+			remote = append(remote, i)
+			continue
+		}
+		lookups[i].value, lookups[i].meta, lookups[i].err = decode[T](c, codec, key, data, true)
+	}
+
+	if len(remote) == 0 {
+		return lookups, nil
+	}
+
+	remoteKeys := make([]string, len(remote))
+	for j, i := range remote {
+		remoteKeys[j] = keys[i]
+	}
+
+	values, err := c.store.GetMany(ctx, remoteKeys)
+	if err != nil {
+		return nil, fault.New("failed to get from cache", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+
+	for j, i := range remote {
+		if values[j] == nil {
+			lookups[i].err = fault.New("key not found", fault.WithTag(fault.NotFound))
+			continue
+		}
+		lookups[i].value, lookups[i].meta, lookups[i].err = decode[T](c, codec, keys[i], values[j], false)
+	}
+
+	return lookups, nil
+}
+
+// loadMany calls loader with the missing keys and stores the values it
+// returns, negatively caching the keys it does not return when params allow
+// it. Storage failures are logged.
+func loadMany[T any](ctx context.Context, params SetParams, missing []string, loader func([]string) (map[string]T, error)) (map[string]T, error) {
+	start := time.Now()
+	loaded, err := loader(missing)
+	if err != nil {
+		return nil, err
+	}
+	computeDuration := time.Since(start)
+
+	notFound := fault.NewNotFound("key not found")
+	f, negative := negativeFault(params, notFound)
+
+	entries := make([]StoreItem, 0, len(missing))
+	for _, key := range missing {
+		p := params
+		p.Key = key
+
+		value, ok := loaded[key]
+		if !ok {
+			if !negative {
+				continue
+			}
+			data, err := encodeNegative(p, f, computeDuration)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, StoreItem{Key: key, Value: data, TTL: params.NegativeTTL})
+			continue
+		}
+
+		data, err := encode(p, value, computeDuration)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, StoreItem{Key: key, Value: data, TTL: params.TTL})
+	}
+
+	keys := make([]string, len(entries))
+	for i, entry := range entries {
+		keys[i] = entry.Key
+	}
+
+	// The tag sets must outlive every entry; a zero TTL never expires.
+	tagTTL := params.TTL
+	if tagTTL > 0 {
+		tagTTL = max(tagTTL, params.NegativeTTL)
+	}
+
+	err = params.Client.tag(ctx, keys, params.Tags, tagTTL)
+	if err == nil {
+		err = setMany(ctx, params.Client, entries)
+	}
+	if err != nil {
+		if params.Client.failClosed() {
+			return nil, err
+		}
+		params.Client.log.Warn("failed to save to cache", "keys", missing, "error", err)
+	}
+
+	return loaded, nil
+}
+
+// refreshMany reloads stale keys in the background. Failures are logged and
+// the cached values are left in place. Identical concurrent refreshes in
+// the process are shared.
+func refreshMany[T any](ctx context.Context, params SetParams, keys []string, loader func([]string) (map[string]T, error)) {
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		res := <-params.Client.flight.DoChan("many:"+strings.Join(keys, ","), func() (any, error) {
+			return loadMany(ctx, params, keys, loader)
+		})
+		if res.Err != nil {
+			params.Client.log.Warn("failed to refresh cache entries", "keys", keys, "error", res.Err)
+		}
+	}()
+}
+
+func setMany(ctx context.Context, c *Client, items []StoreItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err := c.store.SetMany(ctx, items); err != nil {
+		return fault.New("failed to set cache", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+
+	c.storeLocalMany(ctx, items)
+	return nil
+}
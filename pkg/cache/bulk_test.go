@@ -0,0 +1,79 @@
+package cache_test
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+)
+
+func TestGetOrSetMany(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient()
+	params := cache.SetParams{Client: client, TTL: time.Minute}
+
+	if err := cache.SetMany(ctx, client, cache.Item[string]{Key: "a", Value: "cached", TTL: time.Minute}); err != nil {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+
+	var asked []string
+	loader := func(missing []string) (map[string]string, error) {
+		asked = append(asked, missing...)
+		// "c" does not exist and "z" was not requested.
+		return map[string]string{"b": "loaded", "z": "extra"}, nil
+	}
+
+	results, err := cache.GetOrSetMany(ctx, params, []string{"a", "b", "c", "b"}, loader)
+	if err != nil {
+		t.Fatalf("GetOrSetMany() error = %v", err)
+	}
+
+	want := []cache.Result[string]{
+		{Value: "cached", Found: true},
+		{Value: "loaded", Found: true},
+		{},
+		{Value: "loaded", Found: true},
+	}
+	if !slices.Equal(results, want) {
+		t.Errorf("GetOrSetMany() = %v, want %v", results, want)
+	}
+	if !slices.Equal(asked, []string{"b", "c"}) {
+		t.Errorf("loader asked for %v, want [b c]", asked)
+	}
+
+	stored, err := cache.GetMany[string](ctx, client, "b")
+	if err != nil || stored["b"] != "loaded" {
+		t.Errorf("GetMany(b) = %v, %v, want loaded", stored, err)
+	}
+}
+
+func TestSetManyTagsEveryItem(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient()
+
+	err := cache.SetMany(ctx, client,
+		cache.Item[string]{Key: "user:1", Value: "ana", TTL: time.Minute, Tags: []string{"users"}},
+		cache.Item[string]{Key: "user:2", Value: "bob", TTL: time.Minute, Tags: []string{"users", "admins"}},
+		cache.Item[string]{Key: "post:1", Value: "hello", TTL: time.Minute},
+	)
+	if err != nil {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+
+	if err := cache.InvalidateTags(ctx, client, "users"); err != nil {
+		t.Fatalf("InvalidateTags() error = %v", err)
+	}
+
+	values, err := cache.GetMany[string](ctx, client, "user:1", "user:2", "post:1")
+	if err != nil {
+		t.Fatalf("GetMany() error = %v", err)
+	}
+
+	want := map[string]string{"post:1": "hello"}
+	if !maps.Equal(values, want) {
+		t.Errorf("GetMany() = %v, want %v", values, want)
+	}
+}
@@ -2,89 +2,468 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/bernardinorafael/gogem/pkg/fault"
-	"github.com/charmbracelet/log"
+	"github.com/bernardinorafael/gogem/pkg/logger"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
 type SetParams struct {
 	Client *Client
 	Key    string
-	TTL    time.Duration
+	// TTL is the hard TTL: the entry is evicted once it elapses.
+	TTL time.Duration
+	// SoftTTL, when set, is how long the value is fresh. Past it and until
+	// TTL, GetOrSet returns the stale value immediately and refreshes it in
+	// the background.
+	SoftTTL time.Duration
+	// Beta enables XFetch probabilistic early recomputation in GetOrSet:
+	// values are refreshed in the background shortly before they expire,
+	// earlier the longer they took to compute. 1 is a good default; higher
+	// values refresh earlier, 0 disables it.
+	Beta float64
+	// NegativeTTL, when set, makes GetOrSet cache the faults returned by the
+	// callback whose tag is in NegativeTags for this long, and return the
+	// same fault on later calls without running the callback. Faults with a
+	// 5xx HTTP code and errors that are not faults are never cached.
+	NegativeTTL time.Duration
+	// NegativeTags are the fault tags cached when NegativeTTL is set.
+	// Defaults to fault.NotFound.
+	NegativeTags []fault.Tag
+	// Tags associates the entry with tags, so it can be removed along with
+	// every other entry sharing one of them with InvalidateTags.
+	Tags []string
+
+	// codec overrides the client's codec; it is set by Cache handles.
+	codec Codec
 }
 
 type Client struct {
-	redis *redis.Client
-	log   *log.Logger
+	store       Store
+	broadcaster Broadcaster
+	log         logger.Logger
+	observers   []Observer
+	flight      singleflight.Group
+	lock        *lockConfig
+	waitTimeout time.Duration
+	local       *localCache
+	tagPrefix   string
+
+	codec                Codec
+	schemaVersion        uint64
+	compression          Compression
+	compressionThreshold int
+
+	failurePolicy FailurePolicy
+	opTimeout     time.Duration
+	breaker       *breaker
+}
+
+// New returns a client backed by Redis. Any redis.UniversalClient works, so
+// cluster and sentinel deployments are supported.
+func New(redis redis.UniversalClient, log logger.Logger, opts ...func(*Client)) *Client {
+	return NewWithStore(NewRedisStore(redis), log, opts...)
+}
+
+// NewWithStore returns a client backed by store, e.g. a MemoryStore in unit
+// tests.
+func NewWithStore(store Store, log logger.Logger, opts ...func(*Client)) *Client {
+	c := Client{
+		store:     store,
+		log:       log,
+		tagPrefix: "tag:",
+		codec:     JSON,
+	}
+
+	for _, fn := range opts {
+		fn(&c)
+	}
+
+	// Broadcasts bypass the breaker: they are best effort and already
+	// logged when they fail.
+	c.broadcaster, _ = store.(Broadcaster)
+
+	if c.opTimeout > 0 || c.breaker != nil {
+		if c.breaker != nil {
+			c.breaker.log = log
+		}
+		c.store = &resilientStore{store: store, timeout: c.opTimeout, breaker: c.breaker}
+	}
+
+	if c.local != nil {
+		c.subscribe()
+	}
+
+	return &c
 }
 
-func New(redis *redis.Client, log *log.Logger) *Client {
-	return &Client{
-		redis: redis,
-		log:   log,
+// Ping checks the connection to the store. Its signature matches server.Checker,
+// so it can be registered as a health check.
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.store.Ping(ctx); err != nil {
+		return fault.New("failed to ping cache", fault.WithTag(fault.DB), fault.WithErr(err))
 	}
+	return nil
 }
 
 func GetOrSet[T any](ctx context.Context, params SetParams, callback func() (T, error)) (T, error) {
 	var zero T
 
-	cached, err := get[T](ctx, params.Client, params.Key)
+	ctx, finish := params.Client.observe(ctx, OpGetOrSet, params.Key)
+
+	cached, meta, err := get[T](ctx, params.Client, params.Key, params.codec)
+	if meta.Negative {
+		finish(OutcomeHit, nil)
+		return zero, err
+	}
 	if err == nil {
+		now := time.Now()
+		switch {
+		case meta.Stale(now):
+			refresh(ctx, params, callback)
+			finish(OutcomeStale, nil)
+		case meta.refreshEarly(now, params.Beta):
+			refresh(ctx, params, callback)
+			finish(OutcomeHit, nil)
+		default:
+			finish(OutcomeHit, nil)
+		}
 		return cached, nil
 	}
 
-	value, err := callback()
+	outcome := OutcomeMiss
+	if fault.GetTag(err) != fault.NotFound {
+		outcome = OutcomeError
+		if params.Client.failClosed() {
+			finish(outcome, err)
+			return zero, err
+		}
+	}
+
+	value, err := load(ctx, params, callback)
 	if err != nil {
+		finish(outcome, err)
 		return zero, err
 	}
 
-	if err = set(ctx, params.Client, params.Key, value, params.TTL); err != nil {
-		params.Client.log.Warn("failed to save to cache", "key", params.Key, "error", err)
+	finish(outcome, nil)
+	return value, nil
+}
+
+// Get returns the cached value stored under key. A missing key is reported
+// as a fault tagged NotFound.
+func Get[T any](ctx context.Context, c *Client, key string) (T, error) {
+	value, _, err := GetWithMetadata[T](ctx, c, key)
+	return value, err
+}
+
+// GetWithMetadata is like Get and also returns when and how the value was
+// computed. For negatively cached entries it returns the cached fault along
+// with metadata whose Negative field is set.
+func GetWithMetadata[T any](ctx context.Context, c *Client, key string) (T, Metadata, error) {
+	return getWithMetadata[T](ctx, c, key, nil)
+}
+
+func getWithMetadata[T any](ctx context.Context, c *Client, key string, codec Codec) (T, Metadata, error) {
+	ctx, finish := c.observe(ctx, OpGet, key)
+
+	value, meta, err := get[T](ctx, c, key, codec)
+	switch {
+	case err == nil, meta.Negative:
+		finish(OutcomeHit, nil)
+	case fault.GetTag(err) == fault.NotFound:
+		finish(OutcomeMiss, nil)
+	default:
+		finish(OutcomeError, err)
 	}
 
-	return value, nil
+	return value, meta, err
+}
+
+// Set serializes value with the client's codec and stores it under
+// params.Key with params.TTL and params.SoftTTL.
+func Set[T any](ctx context.Context, params SetParams, value T) error {
+	ctx, finish := params.Client.observe(ctx, OpSet, params.Key)
+
+	err := set(ctx, params, value, 0)
+	finish(outcomeOf(err), err)
+
+	return err
+}
+
+// SetNX stores value under params.Key only if the key does not exist yet.
+// It reports whether the value was stored, which makes it suitable for
+// acquiring short-lived locks.
+func SetNX[T any](ctx context.Context, params SetParams, value T) (bool, error) {
+	ctx, finish := params.Client.observe(ctx, OpSetNX, params.Key)
+
+	ok, err := setNX(ctx, params, value)
+	finish(outcomeOf(err), err)
+
+	return ok, err
 }
 
 func Delete(ctx context.Context, c *Client, keys ...string) error {
-	if err := c.redis.Del(ctx, keys...).Err(); err != nil {
-		return fault.New("failed to delete from cache", fault.WithTag(fault.DB), fault.WithErr(err))
+	ctx, finish := c.observe(ctx, OpDelete, strings.Join(keys, ","))
+
+	err := c.store.Delete(ctx, keys...)
+	if err != nil {
+		err = fault.New("failed to delete from cache", fault.WithTag(fault.DB), fault.WithErr(err))
+	} else {
+		c.invalidate(ctx, keys...)
 	}
 
-	return nil
+	finish(outcomeOf(err), err)
+	return err
 }
 
-func get[T any](ctx context.Context, c *Client, key string) (T, error) {
+// get reads the value stored under key, decoding it with codec, or with the
+// client's codec when codec is nil.
+func get[T any](ctx context.Context, c *Client, key string, codec Codec) (T, Metadata, error) {
 	var zero T
 
-	data, err := c.redis.Get(ctx, key).Result()
-	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return zero, fault.New("key not found", fault.WithTag(fault.NotFound))
+	data, local := c.readLocal(key)
+	if !local {
+		var err error
+		data, err = c.store.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return zero, Metadata{}, fault.New("key not found", fault.WithTag(fault.NotFound))
+			}
+			return zero, Metadata{}, fault.New("failed to get from cache", fault.WithTag(fault.DB), fault.WithErr(err))
 		}
-		return zero, fault.New("failed to get from cache", fault.WithTag(fault.DB), fault.WithErr(err))
 	}
 
+	return decode[T](c, codec, key, data, local)
+}
+
+// decode parses a stored entry read from the local tier or the store, and
+// keeps it in the local tier when it came from the store.
+func decode[T any](c *Client, codec Codec, key string, data []byte, local bool) (T, Metadata, error) {
+	var zero T
+	codec = c.codecOr(codec)
+
+	// Entries that are not in the entry format, such as those written by
+	// earlier versions of this package, and entries written with another
+	// schema version or codec are treated as misses. Negative entries are
+	// checked too, since handles record their value type in the codec name.
+	e, err := c.decodeEntry(data)
+	if err != nil || e.SchemaVersion != c.schemaVersion || e.Codec != codec.Name() {
+		return zero, Metadata{}, fault.New("key not found", fault.WithTag(fault.NotFound))
+	}
+
+	// A value that no longer unmarshals into T, e.g. after an incompatible
+	// change to T without bumping the schema version, is a miss as well, so
+	// it is recomputed and overwritten instead of failing until it expires.
 	var value T
-	if err := json.Unmarshal([]byte(data), &value); err != nil {
-		return zero, fault.New("failed to deserialize cached value", fault.WithTag(fault.DB), fault.WithErr(err))
+	if e.Fault == nil {
+		if err := codec.Unmarshal(e.Value, &value); err != nil {
+			c.log.Warn("failed to deserialize cached value", "key", key, "error", err)
+			if local {
+				c.local.remove(key)
+			}
+			return zero, Metadata{}, fault.New("key not found", fault.WithTag(fault.NotFound))
+		}
 	}
 
-	return value, nil
+	meta := e.metadata()
+	if !local && c.local != nil {
+		c.local.add(key, data, meta.ExpiresAt)
+	}
+
+	if e.Fault != nil {
+		return zero, meta, e.Fault.fault()
+	}
+
+	return value, meta, nil
+}
+
+func set[T any](ctx context.Context, params SetParams, value T, computeDuration time.Duration) error {
+	data, err := encode(params, value, computeDuration)
+	if err != nil {
+		return err
+	}
+
+	if err := params.Client.tag(ctx, []string{params.Key}, params.Tags, params.TTL); err != nil {
+		return err
+	}
+
+	if err := params.Client.store.Set(ctx, params.Key, data, params.TTL); err != nil {
+		return fault.New("failed to set cache", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+
+	params.Client.storeLocal(ctx, params, data)
+	return nil
 }
 
-func set[T any](ctx context.Context, c *Client, key string, value T, ttl time.Duration) error {
-	data, err := json.Marshal(value)
+func setNX[T any](ctx context.Context, params SetParams, value T) (bool, error) {
+	data, err := encode(params, value, 0)
 	if err != nil {
-		return fault.New("failed to serialize value", fault.WithTag(fault.DB), fault.WithErr(err))
+		return false, err
 	}
 
-	if err := c.redis.Set(ctx, key, data, ttl).Err(); err != nil {
+	if err := params.Client.tag(ctx, []string{params.Key}, params.Tags, params.TTL); err != nil {
+		return false, err
+	}
+
+	ok, err := params.Client.store.SetNX(ctx, params.Key, data, params.TTL)
+	if err != nil {
+		return false, fault.New("failed to set cache", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+
+	if ok {
+		params.Client.storeLocal(ctx, params, data)
+	}
+	return ok, nil
+}
+
+// setNegative caches f under params.Key for params.NegativeTTL.
+func setNegative(ctx context.Context, params SetParams, f *fault.Fault, computeDuration time.Duration) error {
+	data, err := encodeNegative(params, f, computeDuration)
+	if err != nil {
+		return err
+	}
+
+	params.TTL = params.NegativeTTL
+	if err := params.Client.tag(ctx, []string{params.Key}, params.Tags, params.TTL); err != nil {
+		return err
+	}
+
+	if err := params.Client.store.Set(ctx, params.Key, data, params.TTL); err != nil {
 		return fault.New("failed to set cache", fault.WithTag(fault.DB), fault.WithErr(err))
 	}
 
+	params.Client.storeLocal(ctx, params, data)
 	return nil
 }
+
+// encodeNegative serializes f into the stored entry format, expiring after
+// params.NegativeTTL.
+func encodeNegative(params SetParams, f *fault.Fault, computeDuration time.Duration) ([]byte, error) {
+	params.TTL = params.NegativeTTL
+	params.SoftTTL = 0
+
+	e := newEntry(nil, time.Now(), params, computeDuration)
+	e.Fault = newCachedFault(f)
+
+	return params.Client.encodeEntry(e, params.codec)
+}
+
+// encode serializes value and its metadata into the stored entry format.
+func encode[T any](params SetParams, value T, computeDuration time.Duration) ([]byte, error) {
+	c := params.Client
+
+	codec := c.codecOr(params.codec)
+
+	v, err := codec.Marshal(value)
+	if err != nil {
+		return nil, fault.New("failed to serialize value", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+
+	return c.encodeEntry(newEntry(v, time.Now(), params, computeDuration), codec)
+}
+
+// encodeEntry serializes e with the client's schema version and the name of
+// codec (the client's codec when nil), and compresses it.
+func (c *Client) encodeEntry(e entry, codec Codec) ([]byte, error) {
+	e.SchemaVersion = c.schemaVersion
+	e.Codec = c.codecOr(codec).Name()
+
+	data, err := e.marshal()
+	if err == nil {
+		data, err = c.compress(data)
+	}
+	if err != nil {
+		return nil, fault.New("failed to serialize value", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+
+	return data, nil
+}
+
+func (c *Client) codecOr(codec Codec) Codec {
+	if codec != nil {
+		return codec
+	}
+	return c.codec
+}
+
+func (c *Client) decodeEntry(data []byte) (entry, error) {
+	raw, err := decompress(data)
+	if err != nil {
+		return entry{}, err
+	}
+	return unmarshalEntry(raw)
+}
+
+// negativeFault returns the fault in err if params allow caching it.
+func negativeFault(params SetParams, err error) (*fault.Fault, bool) {
+	if params.NegativeTTL <= 0 {
+		return nil, false
+	}
+
+	var f *fault.Fault
+	if !errors.As(err, &f) || f.HTTPCode >= http.StatusInternalServerError {
+		return nil, false
+	}
+
+	tags := params.NegativeTags
+	if len(tags) == 0 {
+		tags = []fault.Tag{fault.NotFound}
+	}
+	if !slices.Contains(tags, f.Tag) {
+		return nil, false
+	}
+
+	return f, true
+}
+
+// readLocal returns the raw entry stored under key in the local tier, if
+// any.
+func (c *Client) readLocal(key string) ([]byte, bool) {
+	if c.local == nil {
+		return nil, false
+	}
+	return c.local.get(key)
+}
+
+// storeLocal broadcasts that params.Key changed and keeps the new entry in
+// the local tier.
+func (c *Client) storeLocal(ctx context.Context, params SetParams, data []byte) {
+	c.storeLocalMany(ctx, []StoreItem{{Key: params.Key, Value: data, TTL: params.TTL}})
+}
+
+// storeLocalMany broadcasts that the keys of items changed and keeps the
+// new entries in the local tier.
+func (c *Client) storeLocalMany(ctx context.Context, items []StoreItem) {
+	if c.local == nil {
+		return
+	}
+
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Key
+	}
+	c.invalidate(ctx, keys...)
+
+	now := time.Now()
+	for _, item := range items {
+		var expiresAt time.Time
+		if item.TTL > 0 {
+			expiresAt = now.Add(item.TTL)
+		}
+		c.local.add(item.Key, item.Value, expiresAt)
+	}
+}
+
+func outcomeOf(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeOK
+}
@@ -0,0 +1,135 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+func TestGetOrSet(t *testing.T) {
+	notFound := fault.NewNotFound("user not found")
+	unavailable := fault.NewServiceUnavailable("database unavailable")
+
+	tests := []struct {
+		name        string
+		params      cache.SetParams
+		result      error
+		wantCalls   int
+		wantErrTag  fault.Tag
+		wantCached  bool
+		wantNegMeta bool
+	}{
+		{
+			name:       "caches values",
+			params:     cache.SetParams{TTL: time.Minute},
+			wantCalls:  1,
+			wantCached: true,
+		},
+		{
+			name:       "does not cache faults by default",
+			params:     cache.SetParams{TTL: time.Minute},
+			result:     notFound,
+			wantCalls:  2,
+			wantErrTag: fault.NotFound,
+		},
+		{
+			name:        "negatively caches not found faults",
+			params:      cache.SetParams{TTL: time.Minute, NegativeTTL: time.Minute},
+			result:      notFound,
+			wantCalls:   1,
+			wantErrTag:  fault.NotFound,
+			wantNegMeta: true,
+		},
+		{
+			name:       "does not negatively cache tags outside NegativeTags",
+			params:     cache.SetParams{TTL: time.Minute, NegativeTTL: time.Minute, NegativeTags: []fault.Tag{fault.Conflict}},
+			result:     notFound,
+			wantCalls:  2,
+			wantErrTag: fault.NotFound,
+		},
+		{
+			name:       "never negatively caches 5xx faults",
+			params:     cache.SetParams{TTL: time.Minute, NegativeTTL: time.Minute, NegativeTags: []fault.Tag{fault.ServiceUnavailable}},
+			result:     unavailable,
+			wantCalls:  2,
+			wantErrTag: fault.ServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, _ := newTestClient()
+
+			params := tt.params
+			params.Client = client
+			params.Key = "user:1"
+
+			calls := 0
+			callback := func() (string, error) {
+				calls++
+				if tt.result != nil {
+					return "", tt.result
+				}
+				return "alice", nil
+			}
+
+			for range 2 {
+				value, err := cache.GetOrSet(ctx, params, callback)
+				if tt.wantErrTag != "" {
+					if got := fault.GetTag(err); got != tt.wantErrTag {
+						t.Fatalf("GetOrSet() error tag = %q, want %q", got, tt.wantErrTag)
+					}
+					continue
+				}
+				if err != nil || value != "alice" {
+					t.Fatalf("GetOrSet() = %q, %v, want alice", value, err)
+				}
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("callback ran %d times, want %d", calls, tt.wantCalls)
+			}
+
+			_, meta, err := cache.GetWithMetadata[string](ctx, client, params.Key)
+			if got := err == nil; got != tt.wantCached {
+				t.Errorf("value cached = %v, want %v (error %v)", got, tt.wantCached, err)
+			}
+			if meta.Negative != tt.wantNegMeta {
+				t.Errorf("Metadata.Negative = %v, want %v", meta.Negative, tt.wantNegMeta)
+			}
+		})
+	}
+}
+
+func TestGetMissingKey(t *testing.T) {
+	client, _ := newTestClient()
+
+	_, err := cache.Get[string](context.Background(), client, "missing")
+	if got := fault.GetTag(err); got != fault.NotFound {
+		t.Errorf("Get() error tag = %q, want %q", got, fault.NotFound)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient()
+
+	params := cache.SetParams{Client: client, Key: "k", TTL: time.Minute}
+	if err := cache.Set(ctx, params, "v"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cache.Delete(ctx, client, "k"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	_, err := cache.Get[string](ctx, client, "k")
+	var f *fault.Fault
+	if !errors.As(err, &f) || f.Tag != fault.NotFound {
+		t.Errorf("Get() after Delete() error = %v, want a NotFound fault", err)
+	}
+}
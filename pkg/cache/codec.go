@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec serializes cached values. Its name is stored with every entry, and
+// entries written with another codec are treated as misses.
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSON encodes values with encoding/json. It is the default codec.
+	JSON Codec = jsonCodec{}
+	// MessagePack encodes values with MessagePack, which is more compact
+	// and faster than JSON and stores []byte as raw bytes. time.Time values
+	// are decoded in the local time zone. Struct fields are named by their
+	// msgpack tags, falling back to json tags.
+	MessagePack Codec = msgpackCodec{}
+	// Gob encodes values with encoding/gob, which keeps Go types intact,
+	// including time.Time zone offsets, but only encodes exported fields.
+	Gob Codec = gobCodec{}
+)
+
+// WithCodec sets the codec values are serialized with. Defaults to JSON.
+func WithCodec(codec Codec) func(*Client) {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
+
+// WithSchemaVersion sets the version stored with every entry. Entries with
+// another version are treated as misses, so bumping it when the shape of
+// cached types changes makes a deploy ignore incompatible data instead of
+// failing to decode it. Defaults to 0.
+func WithSchemaVersion(version uint64) func(*Client) {
+	return func(c *Client) {
+		c.schemaVersion = version
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return "json" }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,108 @@
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+type codecValue struct {
+	Name    string    `json:"name"`
+	Count   int       `json:"count"`
+	Tags    []string  `json:"tags"`
+	Raw     []byte    `json:"raw"`
+	Created time.Time `json:"created"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.FixedZone("BRT", -3*60*60))
+	want := codecValue{
+		Name:    "ana",
+		Count:   3,
+		Tags:    []string{"a", "b"},
+		Raw:     []byte{0, 1, 2, 0xff},
+		Created: created,
+	}
+
+	tests := []struct {
+		name  string
+		codec cache.Codec
+	}{
+		{name: "json", codec: cache.JSON},
+		{name: "msgpack", codec: cache.MessagePack},
+		{name: "gob", codec: cache.Gob},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, _ := newTestClient(cache.WithCodec(tt.codec))
+			params := cache.SetParams{Client: client, Key: "value", TTL: time.Minute}
+
+			if err := cache.Set(ctx, params, want); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			got, err := cache.Get[codecValue](ctx, client, "value")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			if got.Name != want.Name || got.Count != want.Count || len(got.Tags) != len(want.Tags) {
+				t.Errorf("Get() = %+v, want %+v", got, want)
+			}
+			if !bytes.Equal(got.Raw, want.Raw) {
+				t.Errorf("Raw = %v, want %v", got.Raw, want.Raw)
+			}
+			if !got.Created.Equal(want.Created) {
+				t.Errorf("Created = %v, want %v", got.Created, want.Created)
+			}
+		})
+	}
+}
+
+func TestCodecMismatchIsMiss(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewMemoryStore()
+
+	writer := newStoreClient(store, cache.WithCodec(cache.MessagePack))
+	reader := newStoreClient(store, cache.WithCodec(cache.JSON))
+
+	if err := cache.Set(ctx, cache.SetParams{Client: writer, Key: "k", TTL: time.Minute}, "v"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := cache.Get[string](ctx, reader, "k"); fault.GetTag(err) != fault.NotFound {
+		t.Errorf("Get() with another codec error = %v, want NotFound", err)
+	}
+}
+
+func TestMessagePackKeepsBytesAndTimes(t *testing.T) {
+	want := codecValue{
+		Raw:     []byte{0, 1, 2, 0xff},
+		Created: time.Date(2024, 3, 1, 12, 30, 45, 123456789, time.UTC),
+	}
+
+	data, err := cache.MessagePack.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	// Stored as raw bytes, not base64 as with JSON.
+	if !bytes.Contains(data, want.Raw) {
+		t.Errorf("Marshal() = %x, want it to contain the raw bytes %x", data, want.Raw)
+	}
+
+	var got codecValue
+	if err := cache.MessagePack.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !bytes.Equal(got.Raw, want.Raw) {
+		t.Errorf("Raw = %x, want %x", got.Raw, want.Raw)
+	}
+	if !got.Created.Equal(want.Created) || got.Created.Nanosecond() != want.Created.Nanosecond() {
+		t.Errorf("Created = %v, want %v", got.Created, want.Created)
+	}
+}
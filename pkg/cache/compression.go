@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the algorithm entries are compressed with. It is stored as
+// the first byte of every entry, so entries remain readable by clients
+// configured with another algorithm.
+type Compression byte
+
+const (
+	CompressionNone   Compression = 0
+	CompressionGzip   Compression = 1
+	CompressionZstd   Compression = 2
+	CompressionSnappy Compression = 3
+)
+
+// DefaultCompressionThreshold is the size above which entries are compressed
+// when WithCompression is given a threshold of zero.
+const DefaultCompressionThreshold = 1024
+
+// WithCompression compresses entries larger than threshold bytes with
+// algorithm. Smaller entries are stored uncompressed, since compressing them
+// costs more than it saves. A threshold of zero means
+// DefaultCompressionThreshold.
+func WithCompression(algorithm Compression, threshold int) func(*Client) {
+	return func(c *Client) {
+		if threshold <= 0 {
+			threshold = DefaultCompressionThreshold
+		}
+		c.compression = algorithm
+		c.compressionThreshold = threshold
+	}
+}
+
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		enc, _ := zstd.NewWriter(nil)
+		return enc
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		dec, _ := zstd.NewReader(nil)
+		return dec
+	})
+)
+
+// compress prepends the compression header to data, compressing it first
+// when it is larger than the client's threshold.
+func (c *Client) compress(data []byte) ([]byte, error) {
+	algorithm := c.compression
+	if algorithm == CompressionNone || len(data) <= c.compressionThreshold {
+		return append([]byte{byte(CompressionNone)}, data...), nil
+	}
+
+	out := []byte{byte(algorithm)}
+	switch algorithm {
+	case CompressionGzip:
+		buf := bytes.NewBuffer(out)
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		return zstdEncoder().EncodeAll(data, out), nil
+	case CompressionSnappy:
+		return append(out, s2.EncodeSnappy(nil, data)...), nil
+	default:
+		return nil, errors.New("cache: unknown compression algorithm")
+	}
+}
+
+// decompress strips the compression header from data and decompresses it.
+func decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errEntryFormat
+	}
+
+	body := data[1:]
+	switch Compression(data[0]) {
+	case CompressionNone:
+		return body, nil
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case CompressionZstd:
+		return zstdDecoder().DecodeAll(body, nil)
+	case CompressionSnappy:
+		return s2.Decode(nil, body)
+	default:
+		return nil, errEntryFormat
+	}
+}
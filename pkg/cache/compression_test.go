@@ -0,0 +1,128 @@
+package cache_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+func TestCompression(t *testing.T) {
+	const threshold = 256
+	small := "small"
+	large := strings.Repeat("compressible ", 100)
+
+	tests := []struct {
+		name      string
+		algorithm cache.Compression
+	}{
+		{name: "gzip", algorithm: cache.CompressionGzip},
+		{name: "zstd", algorithm: cache.CompressionZstd},
+		{name: "snappy", algorithm: cache.CompressionSnappy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, store := newTestClient(cache.WithCompression(tt.algorithm, threshold))
+
+			for _, c := range []struct {
+				key, value string
+				header     cache.Compression
+			}{
+				{key: "small", value: small, header: cache.CompressionNone},
+				{key: "large", value: large, header: tt.algorithm},
+			} {
+				if err := cache.Set(ctx, cache.SetParams{Client: client, Key: c.key, TTL: time.Minute}, c.value); err != nil {
+					t.Fatalf("Set(%q) error = %v", c.key, err)
+				}
+
+				raw, err := store.Get(ctx, c.key)
+				if err != nil {
+					t.Fatalf("store.Get(%q) error = %v", c.key, err)
+				}
+				if got := cache.Compression(raw[0]); got != c.header {
+					t.Errorf("%s entry header = %d, want %d", c.key, got, c.header)
+				}
+				if c.header != cache.CompressionNone && len(raw) >= len(c.value) {
+					t.Errorf("%s entry is %d bytes, want less than %d", c.key, len(raw), len(c.value))
+				}
+
+				got, err := cache.Get[string](ctx, client, c.key)
+				if err != nil || got != c.value {
+					t.Errorf("Get(%q) = %.20q, %v, want %.20q", c.key, got, err, c.value)
+				}
+			}
+		})
+	}
+}
+
+func TestCompressedEntriesReadableWithoutCompression(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewMemoryStore()
+	writer := newStoreClient(store, cache.WithCompression(cache.CompressionZstd, 16))
+	reader := newStoreClient(store)
+
+	value := strings.Repeat("x", 100)
+	if err := cache.Set(ctx, cache.SetParams{Client: writer, Key: "k", TTL: time.Minute}, value); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := cache.Get[string](ctx, reader, "k"); err != nil || got != value {
+		t.Errorf("Get() = %.20q, %v, want %.20q", got, err, value)
+	}
+}
+
+func TestUnreadableEntriesAreMisses(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, ctx context.Context, store *cache.MemoryStore)
+	}{
+		{
+			name: "schema version mismatch",
+			write: func(t *testing.T, ctx context.Context, store *cache.MemoryStore) {
+				old := newStoreClient(store, cache.WithSchemaVersion(1))
+				if err := cache.Set(ctx, cache.SetParams{Client: old, Key: "k", TTL: time.Minute}, "v"); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "unknown compression header",
+			write: func(t *testing.T, ctx context.Context, store *cache.MemoryStore) {
+				if err := store.Set(ctx, "k", []byte{0x7f, 1, 2, 3}, time.Minute); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "unknown entry format",
+			write: func(t *testing.T, ctx context.Context, store *cache.MemoryStore) {
+				if err := store.Set(ctx, "k", []byte{byte(cache.CompressionNone), 0x7f, 0}, time.Minute); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, store := newTestClient(cache.WithSchemaVersion(2))
+			tt.write(t, ctx, store)
+
+			if _, err := cache.Get[string](ctx, client, "k"); fault.GetTag(err) != fault.NotFound {
+				t.Errorf("Get() error = %v, want NotFound", err)
+			}
+
+			value, err := cache.GetOrSet(ctx, cache.SetParams{Client: client, Key: "k", TTL: time.Minute}, func() (string, error) {
+				return "fresh", nil
+			})
+			if err != nil || value != "fresh" {
+				t.Errorf("GetOrSet() = %q, %v, want fresh", value, err)
+			}
+		})
+	}
+}
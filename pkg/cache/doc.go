@@ -1,9 +1,16 @@
 // Package cache provides a Redis-backed caching layer with a generic
 // cache-aside (GetOrSet) pattern for type-safe cache operations.
 //
-// Creating a cache client:
+// Creating a cache client, with any logger.Logger:
 //
-//	client := cache.New(redisClient, logger)
+//	client := cache.New(redisClient, logger.New())
+//
+// New accepts any redis.UniversalClient, so cluster and sentinel clients
+// work too. The client talks to Redis through the Store interface; other
+// backends are plugged in with NewWithStore. MemoryStore keeps everything in
+// process and honors TTLs, for unit tests and single-instance deployments:
+//
+//	client := cache.NewWithStore(cache.NewMemoryStore(), logger.New())
 //
 // The GetOrSet pattern fetches from cache first, falling back to the callback
 // on a miss and storing the result for subsequent requests:
@@ -16,11 +23,161 @@
 //	    return db.GetUser(ctx, "123")
 //	})
 //
+// A Cache handle declares an entity's key builder, TTL, tags and codec once,
+// and exposes typed Get, Set, GetOrSet and Delete methods. Its entries record
+// the value type, so a key written with another type reads as a miss:
+//
+//	users := cache.NewCache[string, User](client, cache.PrefixKey[string]("user"),
+//	    cache.WithTTL(5*time.Minute),
+//	    cache.WithTags("users"),
+//	)
+//
+//	user, err := users.GetOrSet(ctx, "123", func() (User, error) {
+//	    return db.GetUser(ctx, "123")
+//	})
+//
+// Concurrent GetOrSet calls missing the same key in one process share a
+// single callback run. WithLock extends this across instances with a Redis
+// lock: the instance holding it recomputes the value while the others poll
+// until it is stored, falling back to running the callback themselves when
+// the lock wait elapses:
+//
+//	client := cache.New(rdb, log, cache.WithLock(
+//	    cache.WithLockTTL(30*time.Second),
+//	    cache.WithLockWait(5*time.Second),
+//	))
+//
+// WithLocalCache adds a size-bounded in-process tier in front of the store
+// for small, very hot values, evicting the least recently used values, or the
+// least frequently used ones with WithLocalEviction(EvictLFU). Writes and deletes are broadcast over Redis
+// pub/sub so every instance evicts its local copy; WithLocalTTL bounds how
+// long a copy may outlive a missed invalidation:
+//
+//	client := cache.New(rdb, log, cache.WithLocalCache(10_000, cache.WithLocalTTL(30*time.Second)))
+//	defer client.Close()
+//
+// SoftTTL enables stale-while-revalidate: once it elapses the cached value is
+// still returned immediately while a background refresh recomputes it, until
+// the hard TTL evicts it. Beta enables XFetch probabilistic early refresh,
+// which recomputes values in the background shortly before they expire so
+// hot keys rarely miss at all:
+//
+//	user, err := cache.GetOrSet(ctx, cache.SetParams{
+//	    Client:  client,
+//	    Key:     "user:123",
+//	    SoftTTL: time.Minute,
+//	    TTL:     10 * time.Minute,
+//	    Beta:    1,
+//	}, func() (User, error) {
+//	    return db.GetUser(context.WithoutCancel(ctx), "123")
+//	})
+//
+// Background refreshes run the callback after the request has returned, so it
+// must not depend on the request context being alive; use
+// context.WithoutCancel as above. Refresh failures are logged and leave the
+// cached value in place.
+//
+// NegativeTTL caches faults returned by the callback, NotFound by default or
+// the tags in NegativeTags, so lookups of missing records do not hit the
+// database every time. Later calls return the same fault until NegativeTTL
+// elapses. Errors that are not faults and 5xx faults are never cached:
+//
+//	user, err := cache.GetOrSet(ctx, cache.SetParams{
+//	    Client:      client,
+//	    Key:         "user:" + id,
+//	    TTL:         10 * time.Minute,
+//	    NegativeTTL: 30 * time.Second,
+//	}, func() (User, error) {
+//	    return db.GetUser(ctx, id) // fault.NewNotFound when missing
+//	})
+//
+// Entries are stored with metadata: when the value was computed, how long the
+// callback took and when it becomes stale and expires:
+//
+//	user, meta, err := cache.GetWithMetadata[User](ctx, client, "user:123")
+//	age := time.Since(meta.ComputedAt)
+//
+// Reading and writing entries directly:
+//
+//	err := cache.Set(ctx, cache.SetParams{Client: client, Key: "user:123", TTL: time.Minute}, user)
+//	user, err := cache.Get[User](ctx, client, "user:123") // NotFound fault on miss
+//
+// Bulk operations read and write many keys in a single round trip (MGET and
+// pipelined SETs). GetOrSetMany calls a batch loader with only the keys that
+// missed and returns one result per key, in key order:
+//
+//	err := cache.SetMany(ctx, client,
+//	    cache.Item[User]{Key: "user:1", Value: u1, TTL: time.Minute},
+//	    cache.Item[User]{Key: "user:2", Value: u2, TTL: time.Hour},
+//	)
+//	found, err := cache.GetMany[User](ctx, client, "user:1", "user:2") // map of hits
+//
+//	results, err := cache.GetOrSetMany(ctx, cache.SetParams{Client: client, TTL: time.Minute}, keys,
+//	    func(missing []string) (map[string]User, error) {
+//	        return loadUsers(ctx, missing)
+//	    })
+//
+// SetNX only writes when the key is absent, which is useful for short-lived locks:
+//
+//	acquired, err := cache.SetNX(ctx, cache.SetParams{Client: client, Key: "lock:job", TTL: 30 * time.Second}, true)
+//
 // Deleting cache entries:
 //
 //	err := cache.Delete(ctx, client, "user:123", "user:456")
 //
-// All values are serialized as JSON. Cache write failures are logged as warnings
-// but do not propagate errors, ensuring the primary data source remains the
-// source of truth.
+// Entries can be tagged when written and invalidated by tag, atomically, so
+// everything derived from a record is dropped when it changes:
+//
+//	params := cache.SetParams{Client: client, Key: "user:123", TTL: time.Hour, Tags: []string{"org:42"}}
+//	err := cache.InvalidateTags(ctx, client, "org:42")
+//
+// DeletePrefix removes every key starting with a prefix using SCAN, never
+// KEYS. For whole groups of entries, a Namespace embeds a version in its keys
+// and is invalidated in O(1) by bumping it:
+//
+//	n, err := cache.DeletePrefix(ctx, client, "report:2024:")
+//
+//	users := cache.NewNamespace(client, "users")
+//	key, err := users.Key(ctx, "123") // "users:v0:123"
+//	err = users.Invalidate(ctx)       // keys are now "users:v1:..."
+//
+// Checking the store connection (e.g. as a server health check):
+//
+//	err := client.Ping(ctx)
+//
+// Observers are notified around every operation with its name and outcome
+// (hit, stale, miss, ok or error), e.g. to record metrics:
+//
+//	client := cache.New(rdb, log, cache.WithObserver(m.CacheObserver()))
+//
+// Values are serialized as JSON by default; WithCodec selects MessagePack or
+// gob instead. WithCompression compresses entries above a size threshold
+// with gzip, zstd or snappy; the algorithm is recorded in a header byte, so
+// any client can read any entry. WithSchemaVersion makes entries written
+// with another version count as misses, so a deploy changing cached types
+// recomputes them instead of failing to decode them:
+//
+//	client := cache.New(rdb, log,
+//	    cache.WithCodec(cache.MessagePack),
+//	    cache.WithCompression(cache.CompressionZstd, 4096),
+//	    cache.WithSchemaVersion(3),
+//	)
+//
+// By default GetOrSet fails open: when the store cannot be read the callback
+// serves the request, and failures to store its result are logged as
+// warnings, so the primary data source remains the source of truth.
+// FailClosed returns those failures instead. Store operations can be bounded
+// by a timeout independent of the request context, and a circuit breaker
+// stops calling the store after repeated failures, probing it again later:
+//
+//	client := cache.New(rdb, log,
+//	    cache.WithFailurePolicy(cache.FailOpen),
+//	    cache.WithOperationTimeout(100*time.Millisecond),
+//	    cache.WithCircuitBreaker(cache.WithBreakerThreshold(5), cache.WithBreakerOpenTimeout(30*time.Second)),
+//	)
+//
+// Misses are always reported as faults tagged NotFound, including entries
+// that no longer decode into the requested type; store failures are faults
+// tagged DB, wrapping ErrCircuitOpen while the circuit is open. Requests
+// cancelled or timed out by the caller do not count as store failures.
 package cache
@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+// Metadata describes how and when a cached value was computed.
+type Metadata struct {
+	// ComputedAt is when the value was stored.
+	ComputedAt time.Time
+	// ComputeDuration is how long the GetOrSet callback took to compute the
+	// value, or zero for values stored with Set.
+	ComputeDuration time.Duration
+	// SoftExpiresAt is when the value becomes stale, or zero if it was
+	// stored without a soft TTL.
+	SoftExpiresAt time.Time
+	// ExpiresAt is when the value is evicted, or zero if it never expires.
+	ExpiresAt time.Time
+	// Negative reports whether the entry caches a fault returned by the
+	// GetOrSet callback instead of a value (see SetParams.NegativeTTL).
+	Negative bool
+}
+
+// Stale reports whether the value is past its soft TTL.
+func (m Metadata) Stale(now time.Time) bool {
+	return !m.SoftExpiresAt.IsZero() && !now.Before(m.SoftExpiresAt)
+}
+
+// refreshEarly implements XFetch probabilistic early expiration: the closer
+// the value is to its expiry and the longer it took to compute, the more
+// likely a read triggers a recomputation. See "Optimal Probabilistic Cache
+// Stampede Prevention" (Vattani, Chierichetti, Lowenstein, 2015).
+func (m Metadata) refreshEarly(now time.Time, beta float64) bool {
+	if beta <= 0 || m.ComputeDuration <= 0 {
+		return false
+	}
+
+	expiry := m.SoftExpiresAt
+	if expiry.IsZero() {
+		expiry = m.ExpiresAt
+	}
+	if expiry.IsZero() {
+		return false
+	}
+
+	gap := time.Duration(float64(m.ComputeDuration) * beta * -math.Log(1-rand.Float64()))
+	return !now.Add(gap).Before(expiry)
+}
+
+// entryFormat is the version of the binary entry layout.
+const entryFormat = 1
+
+// entryNegative flags entries holding a fault instead of a value.
+const entryNegative = 1 << 0
+
+// entry is the stored representation of a value and its metadata. Times are
+// Unix milliseconds and the compute duration is in microseconds.
+//
+// It is stored, before compression, as the entryFormat byte, a flags byte,
+// the times, the schema version and the codec name length as uvarints, the
+// codec name, and finally the encoded value (or the fault as JSON).
+type entry struct {
+	Value           []byte
+	Fault           *cachedFault
+	ComputedAt      int64
+	ComputeDuration int64
+	SoftExpiresAt   int64
+	ExpiresAt       int64
+	SchemaVersion   uint64
+	Codec           string
+}
+
+func (e entry) marshal() ([]byte, error) {
+	payload := e.Value
+	var flags byte
+	if e.Fault != nil {
+		flags |= entryNegative
+
+		var err error
+		if payload, err = json.Marshal(e.Fault); err != nil {
+			return nil, err
+		}
+	}
+
+	b := make([]byte, 0, 2+6*binary.MaxVarintLen64+len(e.Codec)+len(payload))
+	b = append(b, entryFormat, flags)
+	b = binary.AppendUvarint(b, uint64(e.ComputedAt))
+	b = binary.AppendUvarint(b, uint64(e.ComputeDuration))
+	b = binary.AppendUvarint(b, uint64(e.SoftExpiresAt))
+	b = binary.AppendUvarint(b, uint64(e.ExpiresAt))
+	b = binary.AppendUvarint(b, e.SchemaVersion)
+	b = binary.AppendUvarint(b, uint64(len(e.Codec)))
+	b = append(b, e.Codec...)
+	return append(b, payload...), nil
+}
+
+// errEntryFormat is returned for data that is not a valid entry, such as
+// entries written by earlier versions of this package.
+var errEntryFormat = errors.New("cache: unknown entry format")
+
+func unmarshalEntry(b []byte) (entry, error) {
+	if len(b) < 2 || b[0] != entryFormat {
+		return entry{}, errEntryFormat
+	}
+	flags := b[1]
+	b = b[2:]
+
+	var fields [6]uint64
+	for i := range fields {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return entry{}, errEntryFormat
+		}
+		fields[i] = v
+		b = b[n:]
+	}
+
+	codecLen := fields[5]
+	if uint64(len(b)) < codecLen {
+		return entry{}, errEntryFormat
+	}
+
+	e := entry{
+		ComputedAt:      int64(fields[0]),
+		ComputeDuration: int64(fields[1]),
+		SoftExpiresAt:   int64(fields[2]),
+		ExpiresAt:       int64(fields[3]),
+		SchemaVersion:   fields[4],
+		Codec:           string(b[:codecLen]),
+	}
+	payload := b[codecLen:]
+
+	if flags&entryNegative != 0 {
+		if err := json.Unmarshal(payload, &e.Fault); err != nil || e.Fault == nil {
+			return entry{}, errEntryFormat
+		}
+		return e, nil
+	}
+
+	e.Value = payload
+	return e, nil
+}
+
+// cachedFault is the stored form of a negatively cached fault. The wrapped
+// error is not stored.
+type cachedFault struct {
+	Tag        fault.Tag          `json:"tag"`
+	HTTPCode   int                `json:"status"`
+	Message    string             `json:"message"`
+	FieldError []fault.FieldError `json:"fields,omitempty"`
+}
+
+func newCachedFault(f *fault.Fault) *cachedFault {
+	return &cachedFault{
+		Tag:        f.Tag,
+		HTTPCode:   f.HTTPCode,
+		Message:    f.Message,
+		FieldError: f.FieldError,
+	}
+}
+
+func (f *cachedFault) fault() *fault.Fault {
+	fields := f.FieldError
+	if fields == nil {
+		fields = make([]fault.FieldError, 0)
+	}
+	return fault.New(f.Message,
+		fault.WithTag(f.Tag),
+		fault.WithHTTPCode(f.HTTPCode),
+		fault.WithFieldError(fields...),
+	)
+}
+
+func newEntry(value []byte, now time.Time, params SetParams, computeDuration time.Duration) entry {
+	e := entry{
+		Value:           value,
+		ComputedAt:      now.UnixMilli(),
+		ComputeDuration: computeDuration.Microseconds(),
+	}
+	if params.SoftTTL > 0 {
+		e.SoftExpiresAt = now.Add(params.SoftTTL).UnixMilli()
+	}
+	if params.TTL > 0 {
+		e.ExpiresAt = now.Add(params.TTL).UnixMilli()
+	}
+	return e
+}
+
+func (e entry) metadata() Metadata {
+	m := Metadata{
+		ComputedAt:      time.UnixMilli(e.ComputedAt),
+		ComputeDuration: time.Duration(e.ComputeDuration) * time.Microsecond,
+		Negative:        e.Fault != nil,
+	}
+	if e.SoftExpiresAt > 0 {
+		m.SoftExpiresAt = time.UnixMilli(e.SoftExpiresAt)
+	}
+	if e.ExpiresAt > 0 {
+		m.ExpiresAt = time.UnixMilli(e.ExpiresAt)
+	}
+	return m
+}
@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+type cacheConfig struct {
+	ttl          time.Duration
+	softTTL      time.Duration
+	beta         float64
+	negativeTTL  time.Duration
+	negativeTags []fault.Tag
+	tags         []string
+	codec        Codec
+}
+
+// WithTTL sets the TTL of the entries written through the handle. See
+// SetParams.TTL.
+func WithTTL(d time.Duration) func(*cacheConfig) {
+	return func(c *cacheConfig) {
+		c.ttl = d
+	}
+}
+
+// WithSoftTTL sets the soft TTL of the entries written through the handle.
+// See SetParams.SoftTTL.
+func WithSoftTTL(d time.Duration) func(*cacheConfig) {
+	return func(c *cacheConfig) {
+		c.softTTL = d
+	}
+}
+
+// WithBeta enables probabilistic early refresh in GetOrSet. See
+// SetParams.Beta.
+func WithBeta(beta float64) func(*cacheConfig) {
+	return func(c *cacheConfig) {
+		c.beta = beta
+	}
+}
+
+// WithNegativeTTL caches the faults tagged with tags (fault.NotFound when
+// none are given) returned by GetOrSet callbacks for d. See
+// SetParams.NegativeTTL.
+func WithNegativeTTL(d time.Duration, tags ...fault.Tag) func(*cacheConfig) {
+	return func(c *cacheConfig) {
+		c.negativeTTL = d
+		c.negativeTags = tags
+	}
+}
+
+// WithTags associates the entries written through the handle with tags. See
+// SetParams.Tags.
+func WithTags(tags ...string) func(*cacheConfig) {
+	return func(c *cacheConfig) {
+		c.tags = tags
+	}
+}
+
+// WithValueCodec sets the codec of the handle's values, overriding the
+// client's codec.
+func WithValueCodec(codec Codec) func(*cacheConfig) {
+	return func(c *cacheConfig) {
+		c.codec = codec
+	}
+}
+
+// Cache is a typed handle over a Client for one kind of entry: it builds
+// keys of type K and stores values of type V with the same TTL and codec
+// everywhere, so each cached entity is declared in one place.
+//
+// Entries record the value type next to the codec name, and an entry of
+// another type is read as a miss. Two handles whose keys collide therefore
+// overwrite each other's entries instead of failing to decode them, and
+// entries written through a handle are not read by Get and GetOrSet, nor
+// the other way around.
+type Cache[K any, V any] struct {
+	client *Client
+	key    func(K) string
+	cfg    cacheConfig
+}
+
+// NewCache returns a handle storing values of type V under the keys built
+// by key.
+//
+// Example:
+//
+//	var users = cache.NewCache[uuid.UUID, User](client, cache.PrefixKey[uuid.UUID]("user"),
+//	    cache.WithTTL(10*time.Minute),
+//	    cache.WithNegativeTTL(time.Minute),
+//	    cache.WithTags("users"),
+//	)
+//
+//	user, err := users.GetOrSet(ctx, id, func() (User, error) {
+//	    return repo.GetUser(ctx, id)
+//	})
+//	err = users.Delete(ctx, id)
+func NewCache[K any, V any](c *Client, key func(K) string, opts ...func(*cacheConfig)) *Cache[K, V] {
+	h := Cache[K, V]{
+		client: c,
+		key:    key,
+	}
+
+	for _, fn := range opts {
+		fn(&h.cfg)
+	}
+
+	h.cfg.codec = typedCodec{
+		Codec:    c.codecOr(h.cfg.codec),
+		typeName: typeName(reflect.TypeFor[V]()),
+	}
+
+	return &h
+}
+
+// typedCodec is the codec of a Cache handle. Its name, stored in every
+// entry, includes the value type, so entries of another type are misses.
+type typedCodec struct {
+	Codec
+	typeName string
+}
+
+func (c typedCodec) Name() string {
+	return c.Codec.Name() + ";type=" + c.typeName
+}
+
+// typeName identifies t by the import path of its package and its name, so
+// types named alike in different packages differ, e.g.
+// "map[string]example.com/app/models.User".
+func typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	default:
+		return t.String()
+	}
+}
+
+// PrefixKey returns a key builder formatting keys as "<prefix>:<key>".
+func PrefixKey[K any](prefix string) func(K) string {
+	return func(k K) string {
+		return fmt.Sprintf("%s:%v", prefix, k)
+	}
+}
+
+// Key returns the cache key of k.
+func (h *Cache[K, V]) Key(k K) string {
+	return h.key(k)
+}
+
+// Get returns the value cached for k. A missing key is reported as a fault
+// tagged NotFound.
+func (h *Cache[K, V]) Get(ctx context.Context, k K) (V, error) {
+	value, _, err := getWithMetadata[V](ctx, h.client, h.key(k), h.cfg.codec)
+	return value, err
+}
+
+// GetWithMetadata is like Get and also returns the entry's metadata.
+func (h *Cache[K, V]) GetWithMetadata(ctx context.Context, k K) (V, Metadata, error) {
+	return getWithMetadata[V](ctx, h.client, h.key(k), h.cfg.codec)
+}
+
+// Set stores value for k.
+func (h *Cache[K, V]) Set(ctx context.Context, k K, value V) error {
+	return Set(ctx, h.params(k), value)
+}
+
+// GetOrSet returns the value cached for k, calling callback and storing its
+// result on a miss. See the GetOrSet function.
+func (h *Cache[K, V]) GetOrSet(ctx context.Context, k K, callback func() (V, error)) (V, error) {
+	return GetOrSet(ctx, h.params(k), callback)
+}
+
+// Delete removes the entries of keys.
+func (h *Cache[K, V]) Delete(ctx context.Context, keys ...K) error {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = h.key(k)
+	}
+	return Delete(ctx, h.client, names...)
+}
+
+func (h *Cache[K, V]) params(k K) SetParams {
+	return SetParams{
+		Client:       h.client,
+		Key:          h.key(k),
+		TTL:          h.cfg.ttl,
+		SoftTTL:      h.cfg.softTTL,
+		Beta:         h.cfg.beta,
+		NegativeTTL:  h.cfg.negativeTTL,
+		NegativeTags: h.cfg.negativeTags,
+		Tags:         h.cfg.tags,
+		codec:        h.cfg.codec,
+	}
+}
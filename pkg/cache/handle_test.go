@@ -0,0 +1,98 @@
+package cache_test
+
+import (
+	"context"
+	randv1 "math/rand"
+	randv2 "math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+func TestCacheTypeMismatchIsMiss(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient(cache.WithFailurePolicy(cache.FailClosed))
+
+	// Both handles build "user:<id>" keys for different value types.
+	names := cache.NewCache[int, string](client, cache.PrefixKey[int]("user"), cache.WithTTL(time.Minute))
+	ages := cache.NewCache[int, map[string]int](client, cache.PrefixKey[int]("user"), cache.WithTTL(time.Minute))
+
+	if err := names.Set(ctx, 1, "ana"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if _, err := ages.Get(ctx, 1); fault.GetTag(err) != fault.NotFound {
+		t.Errorf("Get() of another type error = %v, want NotFound", err)
+	}
+
+	want := map[string]int{"ana": 30}
+	got, err := ages.GetOrSet(ctx, 1, func() (map[string]int, error) { return want, nil })
+	if err != nil || got["ana"] != 30 {
+		t.Errorf("GetOrSet() = %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestCacheSameNamedTypesDiffer(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient()
+
+	// Both types are named "rand.Rand" and encode as "{}".
+	v1 := cache.NewCache[int, randv1.Rand](client, cache.PrefixKey[int]("rand"), cache.WithTTL(time.Minute))
+	v2 := cache.NewCache[int, randv2.Rand](client, cache.PrefixKey[int]("rand"), cache.WithTTL(time.Minute))
+
+	if err := v1.Set(ctx, 1, randv1.Rand{}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := v2.Get(ctx, 1); fault.GetTag(err) != fault.NotFound {
+		t.Errorf("Get() of a type from another package error = %v, want NotFound", err)
+	}
+}
+
+func TestCacheNegativeEntryTypeMismatchIsMiss(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient()
+
+	names := cache.NewCache[int, string](client, cache.PrefixKey[int]("user"),
+		cache.WithTTL(time.Minute),
+		cache.WithNegativeTTL(time.Minute),
+	)
+	ages := cache.NewCache[int, int](client, cache.PrefixKey[int]("user"), cache.WithTTL(time.Minute))
+
+	_, err := names.GetOrSet(ctx, 1, func() (string, error) {
+		return "", fault.NewNotFound("user not found")
+	})
+	if fault.GetTag(err) != fault.NotFound {
+		t.Fatalf("GetOrSet() error = %v, want NotFound", err)
+	}
+
+	var calls int
+	age, err := ages.GetOrSet(ctx, 1, func() (int, error) {
+		calls++
+		return 30, nil
+	})
+	if err != nil || age != 30 || calls != 1 {
+		t.Errorf("GetOrSet() = %d, %v after %d calls, want 30 from the callback", age, err, calls)
+	}
+}
+
+func TestCacheWithTags(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient()
+	users := cache.NewCache[int, string](client, cache.PrefixKey[int]("user"),
+		cache.WithTTL(time.Minute),
+		cache.WithTags("users"),
+	)
+
+	if err := users.Set(ctx, 1, "ana"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cache.InvalidateTags(ctx, client, "users"); err != nil {
+		t.Fatalf("InvalidateTags() error = %v", err)
+	}
+
+	if _, err := users.Get(ctx, 1); fault.GetTag(err) != fault.NotFound {
+		t.Errorf("Get() after InvalidateTags error = %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,403 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+type localConfig struct {
+	maxEntries int
+	ttl        time.Duration
+	channel    string
+	eviction   Eviction
+}
+
+// Eviction is the policy deciding which value the local tier drops when it
+// is full.
+type Eviction int
+
+const (
+	// EvictLRU drops the least recently used value. It is the default and
+	// suits most workloads.
+	EvictLRU Eviction = iota
+	// EvictLFU drops the least frequently used value, breaking ties by
+	// recency. It keeps a stable set of hot keys through scans of keys read
+	// once, but is slower to adapt when the hot set changes.
+	EvictLFU
+)
+
+// WithLocalEviction sets the eviction policy of the local tier. Defaults to
+// EvictLRU.
+func WithLocalEviction(e Eviction) func(*localConfig) {
+	return func(c *localConfig) {
+		c.eviction = e
+	}
+}
+
+// WithLocalTTL sets how long a value is kept in the local tier. It bounds
+// how long an instance may serve a value that was changed elsewhere while
+// it missed the invalidation, e.g. during a Redis reconnection. Defaults to
+// 10s.
+func WithLocalTTL(d time.Duration) func(*localConfig) {
+	return func(c *localConfig) {
+		c.ttl = d
+	}
+}
+
+// WithInvalidationChannel sets the Redis pub/sub channel invalidations are
+// broadcast on. Instances sharing a cache must use the same channel.
+// Defaults to "cache:invalidate".
+func WithInvalidationChannel(name string) func(*localConfig) {
+	return func(c *localConfig) {
+		c.channel = name
+	}
+}
+
+// WithLocalCache adds an in-process tier holding up to maxEntries values
+// (evicting the least recently used, see WithLocalEviction) in front of the
+// store, so hot keys are served without a round trip. Set,
+// SetNX and Delete broadcast the keys they change (over Redis pub/sub, see
+// Broadcaster) and every instance evicts its local copies. Call Close to
+// stop listening for invalidations.
+//
+// Example:
+//
+//	client := cache.New(rdb, log, cache.WithLocalCache(10_000,
+//	    cache.WithLocalTTL(30*time.Second),
+//	))
+//	defer client.Close()
+func WithLocalCache(maxEntries int, opts ...func(*localConfig)) func(*Client) {
+	return func(c *Client) {
+		cfg := localConfig{
+			maxEntries: maxEntries,
+			ttl:        10 * time.Second,
+			channel:    "cache:invalidate",
+		}
+
+		for _, fn := range opts {
+			fn(&cfg)
+		}
+
+		c.local = newLocalCache(cfg)
+	}
+}
+
+// Close stops listening for invalidations of the local tier. It does not
+// close the Redis client.
+func (c *Client) Close() error {
+	if c.local == nil || c.local.unsubscribe == nil {
+		return nil
+	}
+	return c.local.unsubscribe()
+}
+
+// localCache is a size-bounded map of raw entries with per-entry expiry,
+// evicting according to its policy.
+type localCache struct {
+	cfg         localConfig
+	origin      string
+	unsubscribe func() error
+
+	mu     sync.Mutex
+	policy evictionPolicy
+	items  map[string]*localItem
+}
+
+type localItem struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+
+	// el and freq are maintained by the eviction policy.
+	el   *list.Element
+	freq int
+}
+
+// evictionPolicy orders the items of a localCache. Its methods are called
+// with the cache's lock held.
+type evictionPolicy interface {
+	// insert tracks a new item.
+	insert(item *localItem)
+	// touch records an access to item.
+	touch(item *localItem)
+	// remove stops tracking item.
+	remove(item *localItem)
+	// victim returns the item to evict.
+	victim() *localItem
+	// reset stops tracking every item.
+	reset()
+}
+
+// invalidation is the message broadcast when keys change, or when every key
+// starting with Prefix is deleted. Origin identifies the sending client so
+// it does not evict the values it just stored.
+type invalidation struct {
+	Origin string   `json:"o"`
+	Keys   []string `json:"k,omitempty"`
+	Prefix string   `json:"p,omitempty"`
+}
+
+func newLocalCache(cfg localConfig) *localCache {
+	var policy evictionPolicy
+	switch cfg.eviction {
+	case EvictLFU:
+		policy = newLFU()
+	default:
+		policy = newLRU()
+	}
+
+	return &localCache{
+		cfg:    cfg,
+		origin: lockToken(),
+		policy: policy,
+		items:  make(map[string]*localItem),
+	}
+}
+
+func (l *localCache) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	item, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !time.Now().Before(item.expiresAt) {
+		l.removeItem(item)
+		return nil, false
+	}
+
+	l.policy.touch(item)
+	return item.data, true
+}
+
+// add stores data under key until the local TTL elapses, or until expiresAt
+// if it comes first.
+func (l *localCache) add(key string, data []byte, expiresAt time.Time) {
+	if l.cfg.maxEntries <= 0 {
+		return
+	}
+
+	exp := time.Now().Add(l.cfg.ttl)
+	if !expiresAt.IsZero() && expiresAt.Before(exp) {
+		exp = expiresAt
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if item, ok := l.items[key]; ok {
+		item.data = data
+		item.expiresAt = exp
+		l.policy.touch(item)
+		return
+	}
+
+	for len(l.items) >= l.cfg.maxEntries {
+		l.removeItem(l.policy.victim())
+	}
+
+	item := &localItem{key: key, data: data, expiresAt: exp}
+	l.items[key] = item
+	l.policy.insert(item)
+}
+
+func (l *localCache) remove(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if item, ok := l.items[key]; ok {
+			l.removeItem(item)
+		}
+	}
+}
+
+func (l *localCache) removePrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, item := range l.items {
+		if strings.HasPrefix(key, prefix) {
+			l.removeItem(item)
+		}
+	}
+}
+
+func (l *localCache) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.policy.reset()
+	clear(l.items)
+}
+
+// removeItem removes item from the cache. The caller must hold l.mu.
+func (l *localCache) removeItem(item *localItem) {
+	l.policy.remove(item)
+	delete(l.items, item.key)
+}
+
+// lru orders items from the most to the least recently used.
+type lru struct {
+	order *list.List
+}
+
+func newLRU() *lru {
+	return &lru{order: list.New()}
+}
+
+func (p *lru) insert(item *localItem) {
+	item.el = p.order.PushFront(item)
+}
+
+func (p *lru) touch(item *localItem) {
+	p.order.MoveToFront(item.el)
+}
+
+func (p *lru) remove(item *localItem) {
+	p.order.Remove(item.el)
+}
+
+func (p *lru) victim() *localItem {
+	return p.order.Back().Value.(*localItem)
+}
+
+func (p *lru) reset() {
+	p.order.Init()
+}
+
+// lfu groups items by access count, each group ordered from the most to the
+// least recently used, so every operation runs in constant time.
+type lfu struct {
+	freqs   map[int]*list.List
+	minFreq int
+}
+
+func newLFU() *lfu {
+	return &lfu{freqs: make(map[int]*list.List)}
+}
+
+func (p *lfu) insert(item *localItem) {
+	item.freq = 1
+	p.push(item)
+	p.minFreq = 1
+}
+
+func (p *lfu) touch(item *localItem) {
+	p.unlink(item)
+	if p.freqs[item.freq] == nil && p.minFreq == item.freq {
+		p.minFreq++
+	}
+	item.freq++
+	p.push(item)
+}
+
+func (p *lfu) remove(item *localItem) {
+	p.unlink(item)
+}
+
+func (p *lfu) victim() *localItem {
+	// minFreq may be stale after removals; find the lowest non-empty group.
+	if p.freqs[p.minFreq] == nil {
+		lowest := 0
+		for freq := range p.freqs {
+			if lowest == 0 || freq < lowest {
+				lowest = freq
+			}
+		}
+		p.minFreq = lowest
+	}
+	return p.freqs[p.minFreq].Back().Value.(*localItem)
+}
+
+func (p *lfu) reset() {
+	clear(p.freqs)
+	p.minFreq = 0
+}
+
+func (p *lfu) push(item *localItem) {
+	group := p.freqs[item.freq]
+	if group == nil {
+		group = list.New()
+		p.freqs[item.freq] = group
+	}
+	item.el = group.PushFront(item)
+}
+
+// unlink removes item from its group, dropping the group once empty.
+func (p *lfu) unlink(item *localItem) {
+	group := p.freqs[item.freq]
+	group.Remove(item.el)
+	if group.Len() == 0 {
+		delete(p.freqs, item.freq)
+	}
+}
+
+// subscribe starts listening for invalidations broadcast by other clients
+// when the store supports it. The local tier is purged on every
+// (re)subscription, since invalidations sent while disconnected are lost.
+func (c *Client) subscribe() {
+	if c.broadcaster == nil {
+		return
+	}
+
+	l := c.local
+	l.unsubscribe = c.broadcaster.Subscribe(l.cfg.channel, func(payload []byte) {
+		var inv invalidation
+		if err := json.Unmarshal(payload, &inv); err != nil {
+			c.log.Warn("failed to decode cache invalidation", "error", err)
+			return
+		}
+		if inv.Origin == l.origin {
+			return
+		}
+		l.remove(inv.Keys...)
+		if inv.Prefix != "" {
+			l.removePrefix(inv.Prefix)
+		}
+	}, l.purge)
+}
+
+// invalidate evicts keys from the local tier and broadcasts the change to
+// the other clients.
+func (c *Client) invalidate(ctx context.Context, keys ...string) {
+	if c.local == nil || len(keys) == 0 {
+		return
+	}
+
+	c.local.remove(keys...)
+	c.broadcast(ctx, invalidation{Origin: c.local.origin, Keys: keys})
+}
+
+// invalidatePrefix evicts the keys starting with prefix from the local tier
+// and broadcasts the change to the other clients.
+func (c *Client) invalidatePrefix(ctx context.Context, prefix string) {
+	if c.local == nil {
+		return
+	}
+
+	c.local.removePrefix(prefix)
+	c.broadcast(ctx, invalidation{Origin: c.local.origin, Prefix: prefix})
+}
+
+// broadcast publishes inv when the store supports it. Failures are logged:
+// other instances keep their copies until the local TTL elapses.
+func (c *Client) broadcast(ctx context.Context, inv invalidation) {
+	if c.broadcaster == nil {
+		return
+	}
+
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return
+	}
+	if err := c.broadcaster.Publish(context.WithoutCancel(ctx), c.local.cfg.channel, payload); err != nil {
+		c.log.Warn("failed to broadcast cache invalidation", "keys", inv.Keys, "prefix", inv.Prefix, "error", err)
+	}
+}
@@ -0,0 +1,106 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+)
+
+func TestLocalEviction(t *testing.T) {
+	tests := []struct {
+		name     string
+		eviction cache.Eviction
+		// kept reports whether each key is still served from the local tier
+		// once the store is emptied.
+		kept map[string]bool
+	}{
+		{
+			name:     "LRU evicts the least recently used",
+			eviction: cache.EvictLRU,
+			kept:     map[string]bool{"a": false, "b": true, "c": true},
+		},
+		{
+			name:     "LFU evicts the least frequently used",
+			eviction: cache.EvictLFU,
+			kept:     map[string]bool{"a": true, "b": false, "c": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, store := newTestClient(cache.WithLocalCache(2,
+				cache.WithLocalEviction(tt.eviction),
+				cache.WithLocalTTL(time.Minute),
+			))
+
+			set := func(key string) {
+				t.Helper()
+				if err := cache.Set(ctx, cache.SetParams{Client: client, Key: key, TTL: time.Minute}, key); err != nil {
+					t.Fatalf("Set(%q) error = %v", key, err)
+				}
+			}
+			get := func(key string) {
+				t.Helper()
+				if _, err := cache.Get[string](ctx, client, key); err != nil {
+					t.Fatalf("Get(%q) error = %v", key, err)
+				}
+			}
+
+			set("a")
+			set("b")
+			get("a")
+			get("a")
+			get("b") // b is now the most recently used, a the most frequently used
+			set("c") // evicts one of a and b
+
+			// Remove the keys behind the client's back, so only copies in the
+			// local tier can still be read.
+			if err := store.Delete(ctx, "a", "b", "c"); err != nil {
+				t.Fatal(err)
+			}
+
+			for key, want := range tt.kept {
+				_, err := cache.Get[string](ctx, client, key)
+				if got := err == nil; got != want {
+					t.Errorf("%q served from the local tier = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalNamespaceVersionExpires(t *testing.T) {
+	ctx := context.Background()
+	client, store := newTestClient(cache.WithLocalCache(10, cache.WithLocalTTL(time.Minute)))
+	users := cache.NewNamespace(client, "users")
+
+	key := func() string {
+		t.Helper()
+		key, err := users.Key(ctx, "1")
+		if err != nil {
+			t.Fatalf("Key() error = %v", err)
+		}
+		return key
+	}
+
+	// The version is missing until the first Invalidate, and version 0 is
+	// kept locally as well.
+	key()
+
+	// Bump the version without broadcasting, as if the invalidation was
+	// missed by this instance.
+	if _, err := store.Incr(ctx, "ns:users"); err != nil {
+		t.Fatalf("Incr() error = %v", err)
+	}
+	if got := key(); got != "users:v0:1" {
+		t.Errorf("Key() = %q, want the locally cached %q", got, "users:v0:1")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if got := key(); got != "users:v1:1" {
+		t.Errorf("Key() after expiry = %q, want %q", got, "users:v1:1")
+	}
+}
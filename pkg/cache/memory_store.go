@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore removes expired keys that were
+// not read since they expired.
+const sweepInterval = time.Minute
+
+// MemoryStore is an in-process Store honoring TTLs. It is a drop-in
+// replacement for Redis in unit tests and single-instance deployments.
+// Clients sharing a MemoryStore receive each other's broadcasts.
+type MemoryStore struct {
+	mu        sync.Mutex
+	items     map[string]memoryItem
+	tags      map[string]map[string]struct{}
+	lastSweep time.Time
+
+	subsMu sync.RWMutex
+	nextID uint64
+	subs   map[string]map[uint64]func([]byte)
+}
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+var (
+	_ Store       = (*MemoryStore)(nil)
+	_ Broadcaster = (*MemoryStore)(nil)
+)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		items:     make(map[string]memoryItem),
+		tags:      make(map[string]map[string]struct{}),
+		lastSweep: time.Now(),
+		subs:      make(map[string]map[uint64]func([]byte)),
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.lookup(key, time.Now())
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(item.value), nil
+}
+
+func (s *MemoryStore) GetMany(_ context.Context, keys []string) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		if item, ok := s.lookup(key, now); ok {
+			values[i] = bytes.Clone(item.value)
+		}
+	}
+	return values, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.store(key, value, ttl, now)
+	s.sweep(now)
+	return nil
+}
+
+func (s *MemoryStore) SetMany(_ context.Context, items []StoreItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, item := range items {
+		s.store(item.Key, item.Value, item.TTL, now)
+	}
+	s.sweep(now)
+	return nil
+}
+
+func (s *MemoryStore) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := s.lookup(key, now); ok {
+		return false, nil
+	}
+
+	s.store(key, value, ttl, now)
+	s.sweep(now)
+	return true, nil
+}
+
+func (s *MemoryStore) CompareAndDelete(_ context.Context, key string, value []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.lookup(key, time.Now())
+	if !ok || !bytes.Equal(item.value, value) {
+		return false, nil
+	}
+
+	delete(s.items, key)
+	return true, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.items, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeletePrefix(_ context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	now := time.Now()
+	for key, item := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.items, key)
+			if !item.expired(now) {
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// AddTags keeps the tag sets until DeleteTagged removes them; sets whose
+// keys have all expired are dropped when expired keys are swept.
+func (s *MemoryStore) AddTags(_ context.Context, items []TagItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range items {
+		for _, tagKey := range item.TagKeys {
+			set := s.tags[tagKey]
+			if set == nil {
+				set = make(map[string]struct{})
+				s.tags[tagKey] = set
+			}
+			for _, key := range item.Keys {
+				set[key] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteTagged(_ context.Context, tagKeys []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted []string
+	for _, tagKey := range tagKeys {
+		for key := range s.tags[tagKey] {
+			if _, ok := s.items[key]; ok {
+				delete(s.items, key)
+				deleted = append(deleted, key)
+			}
+		}
+		delete(s.tags, tagKey)
+	}
+	return deleted, nil
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	item, ok := s.lookup(key, now)
+
+	var n int64
+	if ok {
+		var err error
+		n, err = strconv.ParseInt(string(item.value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n++
+	item.value = []byte(strconv.FormatInt(n, 10))
+	s.items[key] = item
+	return n, nil
+}
+
+func (s *MemoryStore) Ping(context.Context) error {
+	return nil
+}
+
+// Len returns the number of keys that have not expired.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Time{})
+	return len(s.items)
+}
+
+// Publish delivers payload synchronously to the subscribers of channel.
+func (s *MemoryStore) Publish(_ context.Context, channel string, payload []byte) error {
+	s.subsMu.RLock()
+	fns := make([]func([]byte), 0, len(s.subs[channel]))
+	for _, fn := range s.subs[channel] {
+		fns = append(fns, fn)
+	}
+	s.subsMu.RUnlock()
+
+	for _, fn := range fns {
+		fn(bytes.Clone(payload))
+	}
+	return nil
+}
+
+func (s *MemoryStore) Subscribe(channel string, onMessage func([]byte), onReset func()) func() error {
+	s.subsMu.Lock()
+	s.nextID++
+	id := s.nextID
+	if s.subs[channel] == nil {
+		s.subs[channel] = make(map[uint64]func([]byte))
+	}
+	s.subs[channel][id] = onMessage
+	s.subsMu.Unlock()
+
+	onReset()
+
+	return func() error {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+
+		delete(s.subs[channel], id)
+		return nil
+	}
+}
+
+// lookup returns the item stored under key, removing it if it has expired.
+// The caller must hold s.mu.
+func (s *MemoryStore) lookup(key string, now time.Time) (memoryItem, bool) {
+	item, ok := s.items[key]
+	if !ok {
+		return memoryItem{}, false
+	}
+	if item.expired(now) {
+		delete(s.items, key)
+		return memoryItem{}, false
+	}
+	return item, true
+}
+
+// store saves value under key. The caller must hold s.mu.
+func (s *MemoryStore) store(key string, value []byte, ttl time.Duration, now time.Time) {
+	item := memoryItem{value: bytes.Clone(value)}
+	if ttl > 0 {
+		item.expiresAt = now.Add(ttl)
+	}
+	s.items[key] = item
+}
+
+// sweep removes expired keys at most once per sweepInterval, or right away
+// when now is zero. The caller must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	force := now.IsZero()
+	if force {
+		now = time.Now()
+	}
+	if !force && now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	s.lastSweep = now
+	for key, item := range s.items {
+		if item.expired(now) {
+			delete(s.items, key)
+		}
+	}
+	for tagKey, set := range s.tags {
+		for key := range set {
+			if _, ok := s.items[key]; !ok {
+				delete(set, key)
+			}
+		}
+		if len(set) == 0 {
+			delete(s.tags, tagKey)
+		}
+	}
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
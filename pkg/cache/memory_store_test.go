@@ -0,0 +1,265 @@
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+)
+
+// storeContract lists the behavior every Store implementation must have.
+var storeContract = []struct {
+	name string
+	run  func(t *testing.T, ctx context.Context, s cache.Store)
+}{
+	{
+		name: "Get reports missing keys with ErrNotFound",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			if _, err := s.Get(ctx, "missing"); !errors.Is(err, cache.ErrNotFound) {
+				t.Errorf("Get() error = %v, want ErrNotFound", err)
+			}
+		},
+	},
+	{
+		name: "Set stores a value read back by Get",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			mustSet(t, ctx, s, "k", "v", 0)
+			assertValue(t, ctx, s, "k", "v")
+		},
+	},
+	{
+		name: "Set expires values after their TTL",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			mustSet(t, ctx, s, "short", "v", 20*time.Millisecond)
+			mustSet(t, ctx, s, "forever", "v", 0)
+			time.Sleep(40 * time.Millisecond)
+
+			if _, err := s.Get(ctx, "short"); !errors.Is(err, cache.ErrNotFound) {
+				t.Errorf("Get() of expired key error = %v, want ErrNotFound", err)
+			}
+			assertValue(t, ctx, s, "forever", "v")
+		},
+	},
+	{
+		name: "GetMany returns values in order with nil for missing keys",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			mustSet(t, ctx, s, "a", "1", 0)
+			mustSet(t, ctx, s, "c", "3", 0)
+
+			values, err := s.GetMany(ctx, []string{"a", "b", "c"})
+			if err != nil {
+				t.Fatalf("GetMany() error = %v", err)
+			}
+			want := [][]byte{[]byte("1"), nil, []byte("3")}
+			if !slices.EqualFunc(values, want, bytes.Equal) || values[1] != nil {
+				t.Errorf("GetMany() = %q, want %q", values, want)
+			}
+		},
+	},
+	{
+		name: "SetMany stores every item with its own TTL",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			err := s.SetMany(ctx, []cache.StoreItem{
+				{Key: "short", Value: []byte("1"), TTL: 20 * time.Millisecond},
+				{Key: "long", Value: []byte("2"), TTL: time.Minute},
+			})
+			if err != nil {
+				t.Fatalf("SetMany() error = %v", err)
+			}
+			assertValue(t, ctx, s, "short", "1")
+			time.Sleep(40 * time.Millisecond)
+
+			if _, err := s.Get(ctx, "short"); !errors.Is(err, cache.ErrNotFound) {
+				t.Errorf("Get() of expired key error = %v, want ErrNotFound", err)
+			}
+			assertValue(t, ctx, s, "long", "2")
+		},
+	},
+	{
+		name: "SetNX only stores missing keys",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			ok, err := s.SetNX(ctx, "k", []byte("first"), time.Minute)
+			if err != nil || !ok {
+				t.Fatalf("first SetNX() = %v, %v, want true", ok, err)
+			}
+			ok, err = s.SetNX(ctx, "k", []byte("second"), time.Minute)
+			if err != nil || ok {
+				t.Fatalf("second SetNX() = %v, %v, want false", ok, err)
+			}
+			assertValue(t, ctx, s, "k", "first")
+		},
+	},
+	{
+		name: "SetNX stores keys whose value expired",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			mustSet(t, ctx, s, "k", "old", 20*time.Millisecond)
+			time.Sleep(40 * time.Millisecond)
+
+			ok, err := s.SetNX(ctx, "k", []byte("new"), time.Minute)
+			if err != nil || !ok {
+				t.Fatalf("SetNX() = %v, %v, want true", ok, err)
+			}
+			assertValue(t, ctx, s, "k", "new")
+		},
+	},
+	{
+		name: "CompareAndDelete only deletes matching values",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			mustSet(t, ctx, s, "lock", "token", 0)
+
+			ok, err := s.CompareAndDelete(ctx, "lock", []byte("other"))
+			if err != nil || ok {
+				t.Fatalf("CompareAndDelete() with another value = %v, %v, want false", ok, err)
+			}
+			assertValue(t, ctx, s, "lock", "token")
+
+			ok, err = s.CompareAndDelete(ctx, "lock", []byte("token"))
+			if err != nil || !ok {
+				t.Fatalf("CompareAndDelete() with the stored value = %v, %v, want true", ok, err)
+			}
+			if _, err := s.Get(ctx, "lock"); !errors.Is(err, cache.ErrNotFound) {
+				t.Errorf("Get() after CompareAndDelete() error = %v, want ErrNotFound", err)
+			}
+		},
+	},
+	{
+		name: "Delete removes keys and ignores missing ones",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			mustSet(t, ctx, s, "a", "1", 0)
+			mustSet(t, ctx, s, "b", "2", 0)
+
+			if err := s.Delete(ctx, "a", "missing"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := s.Get(ctx, "a"); !errors.Is(err, cache.ErrNotFound) {
+				t.Errorf("Get() of deleted key error = %v, want ErrNotFound", err)
+			}
+			assertValue(t, ctx, s, "b", "2")
+		},
+	},
+	{
+		name: "DeletePrefix removes and counts matching keys",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			mustSet(t, ctx, s, "user:1", "1", 0)
+			mustSet(t, ctx, s, "user:2", "2", 0)
+			mustSet(t, ctx, s, "order:1", "3", 0)
+
+			n, err := s.DeletePrefix(ctx, "user:")
+			if err != nil || n != 2 {
+				t.Fatalf("DeletePrefix() = %d, %v, want 2", n, err)
+			}
+			if _, err := s.Get(ctx, "user:1"); !errors.Is(err, cache.ErrNotFound) {
+				t.Errorf("Get() of deleted key error = %v, want ErrNotFound", err)
+			}
+			assertValue(t, ctx, s, "order:1", "3")
+		},
+	},
+	{
+		name: "DeleteTagged removes the tagged keys and returns them",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			mustSet(t, ctx, s, "a", "1", 0)
+			mustSet(t, ctx, s, "b", "2", 0)
+			mustSet(t, ctx, s, "c", "3", 0)
+			if err := s.AddTags(ctx, []cache.TagItem{{Keys: []string{"a", "b"}, TagKeys: []string{"tag:x"}, TTL: time.Minute}}); err != nil {
+				t.Fatalf("AddTags() error = %v", err)
+			}
+			if err := s.AddTags(ctx, []cache.TagItem{{Keys: []string{"c"}, TagKeys: []string{"tag:y"}, TTL: time.Minute}}); err != nil {
+				t.Fatalf("AddTags() error = %v", err)
+			}
+
+			deleted, err := s.DeleteTagged(ctx, []string{"tag:x"})
+			if err != nil {
+				t.Fatalf("DeleteTagged() error = %v", err)
+			}
+			slices.Sort(deleted)
+			if !slices.Equal(deleted, []string{"a", "b"}) {
+				t.Errorf("DeleteTagged() = %v, want [a b]", deleted)
+			}
+			assertValue(t, ctx, s, "c", "3")
+
+			// The tag set is removed along with the keys.
+			deleted, err = s.DeleteTagged(ctx, []string{"tag:x"})
+			if err != nil || len(deleted) != 0 {
+				t.Errorf("second DeleteTagged() = %v, %v, want no keys", deleted, err)
+			}
+		},
+	},
+	{
+		name: "Incr starts from zero",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			for want := int64(1); want <= 3; want++ {
+				n, err := s.Incr(ctx, "counter")
+				if err != nil || n != want {
+					t.Fatalf("Incr() = %d, %v, want %d", n, err, want)
+				}
+			}
+		},
+	},
+	{
+		name: "Ping succeeds",
+		run: func(t *testing.T, ctx context.Context, s cache.Store) {
+			if err := s.Ping(ctx); err != nil {
+				t.Errorf("Ping() error = %v", err)
+			}
+		},
+	},
+}
+
+func TestMemoryStore(t *testing.T) {
+	for _, tt := range storeContract {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, context.Background(), cache.NewMemoryStore())
+		})
+	}
+}
+
+func TestMemoryStoreBroadcast(t *testing.T) {
+	ctx := context.Background()
+	s := cache.NewMemoryStore()
+
+	resets := 0
+	var got [][]byte
+	unsubscribe := s.Subscribe("ch", func(payload []byte) {
+		got = append(got, payload)
+	}, func() { resets++ })
+
+	if resets != 1 {
+		t.Errorf("onReset called %d times on subscribe, want 1", resets)
+	}
+
+	if err := s.Publish(ctx, "ch", []byte("hello")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := s.Publish(ctx, "other", []byte("ignored")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := unsubscribe(); err != nil {
+		t.Fatalf("unsubscribe() error = %v", err)
+	}
+	if err := s.Publish(ctx, "ch", []byte("after")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if len(got) != 1 || string(got[0]) != "hello" {
+		t.Errorf("received %q, want [hello]", got)
+	}
+}
+
+func mustSet(t *testing.T, ctx context.Context, s cache.Store, key, value string, ttl time.Duration) {
+	t.Helper()
+	if err := s.Set(ctx, key, []byte(value), ttl); err != nil {
+		t.Fatalf("Set(%q) error = %v", key, err)
+	}
+}
+
+func assertValue(t *testing.T, ctx context.Context, s cache.Store, key, want string) {
+	t.Helper()
+	got, err := s.Get(ctx, key)
+	if err != nil || string(got) != want {
+		t.Errorf("Get(%q) = %q, %v, want %q", key, got, err, want)
+	}
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+// localVersionTTL bounds how long the local tier keeps a namespace version,
+// shorter than values since an instance missing an Invalidate broadcast
+// would otherwise keep reading a whole namespace of outdated entries.
+const localVersionTTL = time.Second
+
+// Namespace builds keys that embed a version number, so every entry of the
+// namespace is invalidated at once by bumping the version. Entries of older
+// versions are never read again and expire with their TTL.
+//
+// Example:
+//
+//	users := cache.NewNamespace(client, "users")
+//
+//	key, err := users.Key(ctx, id) // "users:v0:<id>"
+//	user, err := cache.GetOrSet(ctx, cache.SetParams{Client: client, Key: key, TTL: time.Hour}, loadUser)
+//
+//	err = users.Invalidate(ctx) // later keys are "users:v1:<id>"
+type Namespace struct {
+	client *Client
+	name   string
+}
+
+func NewNamespace(c *Client, name string) *Namespace {
+	return &Namespace{client: c, name: name}
+}
+
+// Key returns the key joining parts with ":" under the current version of
+// the namespace. It reads the version from the store, or from the local
+// tier when one is configured, where it is kept for at most a second.
+func (n *Namespace) Key(ctx context.Context, parts ...string) (string, error) {
+	version, err := n.version(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	key := n.name + ":v" + strconv.FormatInt(version, 10)
+	if len(parts) > 0 {
+		key += ":" + strings.Join(parts, ":")
+	}
+	return key, nil
+}
+
+// Invalidate bumps the version of the namespace, so keys built afterwards
+// no longer match the entries stored before.
+func (n *Namespace) Invalidate(ctx context.Context) error {
+	c := n.client
+	ctx, finish := c.observe(ctx, OpInvalidateVersion, n.versionKey())
+
+	_, err := c.store.Incr(ctx, n.versionKey())
+	if err != nil {
+		err = fault.New("failed to invalidate cache namespace", fault.WithTag(fault.DB), fault.WithErr(err))
+	} else {
+		c.invalidate(ctx, n.versionKey())
+	}
+
+	finish(outcomeOf(err), err)
+	return err
+}
+
+func (n *Namespace) version(ctx context.Context) (int64, error) {
+	c := n.client
+	key := n.versionKey()
+
+	data, local := c.readLocal(key)
+	if !local {
+		var err error
+		data, err = c.store.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			// Never invalidated: version 0, kept locally like any other.
+			data, err = []byte("0"), nil
+		}
+		if err != nil {
+			return 0, fault.New("failed to get cache namespace version", fault.WithTag(fault.DB), fault.WithErr(err))
+		}
+	}
+
+	version, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0, fault.New("invalid cache namespace version", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+
+	if !local && c.local != nil {
+		c.local.add(key, data, time.Now().Add(localVersionTTL))
+	}
+	return version, nil
+}
+
+func (n *Namespace) versionKey() string {
+	return "ns:" + n.name
+}
@@ -0,0 +1,67 @@
+package cache
+
+import "context"
+
+// Operation names reported to observers.
+const (
+	OpGetOrSet = "get_or_set"
+	OpGet      = "get"
+	OpSet      = "set"
+	OpSetNX    = "set_nx"
+	OpDelete   = "delete"
+
+	OpGetMany      = "get_many"
+	OpSetMany      = "set_many"
+	OpGetOrSetMany = "get_or_set_many"
+
+	OpInvalidateTags    = "invalidate_tags"
+	OpDeletePrefix      = "delete_prefix"
+	OpInvalidateVersion = "invalidate_version"
+)
+
+// Outcomes reported to observers when an operation finishes.
+const (
+	OutcomeHit   = "hit"
+	OutcomeStale = "stale"
+	OutcomeMiss  = "miss"
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+)
+
+// Observer is notified around cache operations, e.g. to record metrics or
+// traces. Start is called before the operation runs and returns the context
+// the operation runs with, along with a function called once it finishes.
+//
+// GetOrSet and Get finish with OutcomeHit, OutcomeMiss or OutcomeError, and
+// GetOrSet with OutcomeStale when it serves a stale value. GetMany and
+// GetOrSetMany finish with OutcomeHit only when every key was found. The
+// other operations finish with OutcomeOK or OutcomeError. Bulk operations
+// report their keys joined by commas.
+type Observer interface {
+	Start(ctx context.Context, op, key string) (context.Context, func(outcome string, err error))
+}
+
+// WithObserver registers an observer on the client. Observers are started in
+// registration order and finished in reverse order.
+func WithObserver(o Observer) func(*Client) {
+	return func(c *Client) {
+		c.observers = append(c.observers, o)
+	}
+}
+
+func (c *Client) observe(ctx context.Context, op, key string) (context.Context, func(outcome string, err error)) {
+	if len(c.observers) == 0 {
+		return ctx, func(string, error) {}
+	}
+
+	finishers := make([]func(string, error), len(c.observers))
+	for i, o := range c.observers {
+		ctx, finishers[i] = o.Start(ctx, op, key)
+	}
+
+	return ctx, func(outcome string, err error) {
+		for i := len(finishers) - 1; i >= 0; i-- {
+			finishers[i](outcome, err)
+		}
+	}
+}
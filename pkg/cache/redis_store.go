@@ -0,0 +1,391 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// compareAndDeleteScript deletes the key only if it holds the expected
+// value, atomically.
+var compareAndDeleteScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// addTagsScript adds ARGV[2..] to the sets in KEYS, extending their expiry
+// to at least ARGV[1] milliseconds, or removing it when ARGV[1] is zero.
+var addTagsScript = redis.NewScript(`
+local ttl = tonumber(ARGV[1])
+for _, tagKey in ipairs(KEYS) do
+	local existed = redis.call("EXISTS", tagKey)
+	local current = redis.call("PTTL", tagKey)
+	for i = 2, #ARGV do
+		redis.call("SADD", tagKey, ARGV[i])
+	end
+	if ttl == 0 then
+		redis.call("PERSIST", tagKey)
+	elseif existed == 0 or (current >= 0 and current < ttl) then
+		redis.call("PEXPIRE", tagKey, ttl)
+	end
+end
+return 0
+`)
+
+// deleteTaggedScript deletes the members of the sets in KEYS and the sets
+// themselves, and returns the deleted members.
+var deleteTaggedScript = redis.NewScript(`
+local deleted = {}
+for _, tagKey in ipairs(KEYS) do
+	for _, key in ipairs(redis.call("SMEMBERS", tagKey)) do
+		redis.call("DEL", key)
+		table.insert(deleted, key)
+	end
+	redis.call("DEL", tagKey)
+end
+return deleted
+`)
+
+// scanCount is the COUNT hint of the SCAN calls made by DeletePrefix, and
+// the number of keys unlinked per round trip.
+const scanCount = 500
+
+// RedisStore is a Store backed by Redis. It accepts any
+// redis.UniversalClient, so standalone, cluster, sentinel and ring clients
+// all work.
+type RedisStore struct {
+	redis redis.UniversalClient
+}
+
+var (
+	_ Store       = (*RedisStore)(nil)
+	_ Broadcaster = (*RedisStore)(nil)
+)
+
+func NewRedisStore(redis redis.UniversalClient) *RedisStore {
+	return &RedisStore{redis: redis}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.redis.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// GetMany uses MGET, or pipelined GETs on cluster clients where the keys
+// may live in different slots.
+func (s *RedisStore) GetMany(ctx context.Context, keys []string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	if _, ok := s.redis.(*redis.ClusterClient); ok {
+		cmds := make([]*redis.StringCmd, len(keys))
+		_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.Get(ctx, key)
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		for i, cmd := range cmds {
+			data, err := cmd.Bytes()
+			switch {
+			case err == nil:
+				values[i] = data
+			case !errors.Is(err, redis.Nil):
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	res, err := s.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range res {
+		if str, ok := v.(string); ok {
+			values[i] = []byte(str)
+		}
+	}
+	return values, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.redis.Set(ctx, key, value, ttl).Err()
+}
+
+// SetMany pipelines one SET per item.
+func (s *RedisStore) SetMany(ctx context.Context, items []StoreItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, item := range items {
+			pipe.Set(ctx, item.Key, item.Value, item.TTL)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.redis.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s *RedisStore) CompareAndDelete(ctx context.Context, key string, value []byte) (bool, error) {
+	n, err := compareAndDeleteScript.Run(ctx, s.redis, []string{key}, value).Int()
+	return n > 0, err
+}
+
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.redis.Del(ctx, keys...).Err()
+}
+
+// DeletePrefix walks the keyspace with SCAN, on every master of a cluster
+// or shard of a ring, and unlinks the matching keys in batches. Keys
+// written while it runs may be missed.
+func (s *RedisStore) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	pattern := escapeGlob(prefix) + "*"
+
+	var deleted atomic.Int64
+	fn := func(ctx context.Context, node *redis.Client) error {
+		n, err := scanDelete(ctx, node, pattern)
+		deleted.Add(int64(n))
+		return err
+	}
+
+	var err error
+	switch rdb := s.redis.(type) {
+	case *redis.ClusterClient:
+		err = rdb.ForEachMaster(ctx, fn)
+	case *redis.Ring:
+		err = rdb.ForEachShard(ctx, fn)
+	default:
+		var n int
+		n, err = scanDelete(ctx, s.redis, pattern)
+		deleted.Add(int64(n))
+	}
+
+	return int(deleted.Load()), err
+}
+
+// AddTags runs a script touching the tag sets of each item, pipelined when
+// there are several. On cluster and ring clients the script runs once per
+// tag set, so tag sets need not share a node.
+func (s *RedisStore) AddTags(ctx context.Context, items []TagItem) error {
+	items = slices.DeleteFunc(slices.Clone(items), func(item TagItem) bool {
+		return len(item.Keys) == 0 || len(item.TagKeys) == 0
+	})
+
+	if s.sharded() {
+		var split []TagItem
+		for _, item := range items {
+			for _, tagKey := range item.TagKeys {
+				split = append(split, TagItem{Keys: item.Keys, TagKeys: []string{tagKey}, TTL: item.TTL})
+			}
+		}
+		items = split
+	}
+
+	switch len(items) {
+	case 0:
+		return nil
+	case 1:
+		return addTagsScript.Run(ctx, s.redis, items[0].TagKeys, addTagsArgs(items[0])...).Err()
+	}
+
+	err := s.addTagsPipelined(ctx, items, addTagsScript.EvalSha)
+	if err != nil && redis.HasErrorPrefix(err, "NOSCRIPT") {
+		// The script is not cached yet; send its body, which caches it.
+		err = s.addTagsPipelined(ctx, items, addTagsScript.Eval)
+	}
+	return err
+}
+
+func (s *RedisStore) addTagsPipelined(ctx context.Context, items []TagItem, eval func(context.Context, redis.Scripter, []string, ...any) *redis.Cmd) error {
+	_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, item := range items {
+			eval(ctx, pipe, item.TagKeys, addTagsArgs(item)...)
+		}
+		return nil
+	})
+	return err
+}
+
+func addTagsArgs(item TagItem) []any {
+	args := make([]any, 0, len(item.Keys)+1)
+	args = append(args, item.TTL.Milliseconds())
+	for _, key := range item.Keys {
+		args = append(args, key)
+	}
+	return args
+}
+
+// DeleteTagged runs a script deleting the tagged keys atomically. On cluster
+// and ring clients, where the tagged keys live on other nodes than their tag
+// sets, it reads the sets with SMEMBERS and deletes the keys in a pipeline
+// sending each to its node instead; keys tagged meanwhile may be missed.
+func (s *RedisStore) DeleteTagged(ctx context.Context, tagKeys []string) ([]string, error) {
+	if len(tagKeys) == 0 {
+		return nil, nil
+	}
+	if s.sharded() {
+		return s.deleteTaggedSharded(ctx, tagKeys)
+	}
+	return deleteTaggedScript.Run(ctx, s.redis, tagKeys).StringSlice()
+}
+
+func (s *RedisStore) deleteTaggedSharded(ctx context.Context, tagKeys []string) ([]string, error) {
+	members := make([]*redis.StringSliceCmd, len(tagKeys))
+	_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, tagKey := range tagKeys {
+			members[i] = pipe.SMembers(ctx, tagKey)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	seen := make(map[string]struct{})
+	for _, cmd := range members {
+		for _, key := range cmd.Val() {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	_, err = s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		for _, tagKey := range tagKeys {
+			pipe.Del(ctx, tagKey)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// sharded reports whether keys may live on different nodes, so commands
+// and scripts cannot span several keys.
+func (s *RedisStore) sharded() bool {
+	switch s.redis.(type) {
+	case *redis.ClusterClient, *redis.Ring:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string) (int64, error) {
+	return s.redis.Incr(ctx, key).Result()
+}
+
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.redis.Ping(ctx).Err()
+}
+
+func (s *RedisStore) Publish(ctx context.Context, channel string, payload []byte) error {
+	return s.redis.Publish(ctx, channel, payload).Err()
+}
+
+// Subscribe listens on channel with Redis pub/sub. go-redis reconnects
+// dropped subscriptions on its own, and onReset is called each time.
+func (s *RedisStore) Subscribe(channel string, onMessage func([]byte), onReset func()) func() error {
+	pubsub := s.redis.Subscribe(context.Background(), channel)
+
+	go func() {
+		for msg := range pubsub.ChannelWithSubscriptions() {
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				if msg.Kind == "subscribe" {
+					onReset()
+				}
+			case *redis.Message:
+				onMessage([]byte(msg.Payload))
+			}
+		}
+	}()
+
+	return pubsub.Close
+}
+
+// scanDelete unlinks the keys matching pattern on a single node.
+func scanDelete(ctx context.Context, rdb redis.Cmdable, pattern string) (int, error) {
+	deleted := 0
+	batch := make([]string, 0, scanCount)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		// One UNLINK per key, since keys in a batch may belong to
+		// different cluster slots.
+		_, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range batch {
+				pipe.Unlink(ctx, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		deleted += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	iter := rdb.Scan(ctx, 0, pattern, scanCount).Iterator()
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == scanCount {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
+	}
+
+	return deleted, flush()
+}
+
+// escapeGlob escapes the characters with a special meaning in SCAN MATCH
+// patterns.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
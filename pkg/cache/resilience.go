@@ -0,0 +1,295 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/logger"
+)
+
+// ErrCircuitOpen is returned, wrapped in a fault, for operations rejected
+// while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("cache: circuit breaker open")
+
+// FailurePolicy decides what GetOrSet and GetOrSetMany do when the store
+// fails, as opposed to when a key is missing.
+type FailurePolicy int
+
+const (
+	// FailOpen serves requests from the callback when the store cannot be
+	// read, and only logs failures to store its result. It is the default:
+	// the cache is an optimization and the data source the source of truth.
+	FailOpen FailurePolicy = iota
+	// FailClosed returns store failures to the caller, for callers relying
+	// on the cache to protect a data source that cannot take the full load.
+	FailClosed
+)
+
+// WithFailurePolicy sets what GetOrSet and GetOrSetMany do when the store
+// fails. Defaults to FailOpen.
+func WithFailurePolicy(policy FailurePolicy) func(*Client) {
+	return func(c *Client) {
+		c.failurePolicy = policy
+	}
+}
+
+// WithOperationTimeout bounds every store operation to d. Operations run
+// with a context detached from the caller's cancellation, so a cancelled
+// request does not abort a write halfway, while still carrying its values
+// such as trace spans.
+func WithOperationTimeout(d time.Duration) func(*Client) {
+	return func(c *Client) {
+		c.opTimeout = d
+	}
+}
+
+type breakerConfig struct {
+	threshold   int
+	openTimeout time.Duration
+}
+
+// WithBreakerThreshold sets how many consecutive store failures open the
+// circuit. Defaults to 5.
+func WithBreakerThreshold(n int) func(*breakerConfig) {
+	return func(c *breakerConfig) {
+		c.threshold = n
+	}
+}
+
+// WithBreakerOpenTimeout sets how long the circuit stays open before a
+// single operation is let through to probe the store. Defaults to 30s.
+func WithBreakerOpenTimeout(d time.Duration) func(*breakerConfig) {
+	return func(c *breakerConfig) {
+		c.openTimeout = d
+	}
+}
+
+// WithCircuitBreaker stops calling the store after repeated failures, so a
+// Redis outage costs requests nothing instead of a timeout each. While the
+// circuit is open, operations fail right away with ErrCircuitOpen; once the
+// open timeout elapses one operation probes the store and closes the circuit
+// if it succeeds. Combined with FailOpen, requests are served from the
+// callbacks during the outage.
+//
+// Example:
+//
+//	client := cache.New(rdb, log,
+//	    cache.WithOperationTimeout(100*time.Millisecond),
+//	    cache.WithCircuitBreaker(cache.WithBreakerThreshold(10)),
+//	)
+func WithCircuitBreaker(opts ...func(*breakerConfig)) func(*Client) {
+	return func(c *Client) {
+		cfg := breakerConfig{
+			threshold:   5,
+			openTimeout: 30 * time.Second,
+		}
+
+		for _, fn := range opts {
+			fn(&cfg)
+		}
+
+		c.breaker = &breaker{cfg: cfg}
+	}
+}
+
+// failClosed reports whether store failures are returned to the caller.
+func (c *Client) failClosed() bool {
+	return c.failurePolicy == FailClosed
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker is a consecutive-failures circuit breaker.
+type breaker struct {
+	cfg breakerConfig
+	log logger.Logger
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// allow reports whether an operation may call the store. In the half-open
+// state only the probing operation is allowed.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cfg.openTimeout {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the result of an allowed operation.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		if b.state != breakerClosed {
+			b.log.Info("cache circuit breaker closed")
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.cfg.threshold {
+		if b.state == breakerClosed {
+			b.log.Warn("cache circuit breaker opened", "failures", b.failures, "error", err)
+		}
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release ends an allowed operation without a result. A probe that ends
+// this way lets the next operation probe again.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+// resilientStore applies the operation timeout and the circuit breaker to
+// every call to the underlying store.
+type resilientStore struct {
+	store   Store
+	timeout time.Duration
+	breaker *breaker
+}
+
+func (s *resilientStore) do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.breaker != nil && !s.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+		defer cancel()
+	}
+
+	err := fn(ctx)
+	if s.breaker != nil {
+		switch {
+		case errors.Is(err, context.Canceled),
+			s.timeout == 0 && errors.Is(err, context.DeadlineExceeded):
+			// Cancelled or timed out by the caller, whose deadline applies
+			// when there is no operation timeout: says nothing about the
+			// store.
+			s.breaker.release()
+		case errors.Is(err, ErrNotFound):
+			s.breaker.record(nil)
+		default:
+			s.breaker.record(err)
+		}
+	}
+	return err
+}
+
+func (s *resilientStore) Get(ctx context.Context, key string) (data []byte, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		data, err = s.store.Get(ctx, key)
+		return err
+	})
+	return data, err
+}
+
+func (s *resilientStore) GetMany(ctx context.Context, keys []string) (values [][]byte, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		values, err = s.store.GetMany(ctx, keys)
+		return err
+	})
+	return values, err
+}
+
+func (s *resilientStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.do(ctx, func(ctx context.Context) error {
+		return s.store.Set(ctx, key, value, ttl)
+	})
+}
+
+func (s *resilientStore) SetMany(ctx context.Context, items []StoreItem) error {
+	return s.do(ctx, func(ctx context.Context) error {
+		return s.store.SetMany(ctx, items)
+	})
+}
+
+func (s *resilientStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (ok bool, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		ok, err = s.store.SetNX(ctx, key, value, ttl)
+		return err
+	})
+	return ok, err
+}
+
+func (s *resilientStore) CompareAndDelete(ctx context.Context, key string, value []byte) (ok bool, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		ok, err = s.store.CompareAndDelete(ctx, key, value)
+		return err
+	})
+	return ok, err
+}
+
+func (s *resilientStore) Delete(ctx context.Context, keys ...string) error {
+	return s.do(ctx, func(ctx context.Context) error {
+		return s.store.Delete(ctx, keys...)
+	})
+}
+
+func (s *resilientStore) DeletePrefix(ctx context.Context, prefix string) (n int, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		n, err = s.store.DeletePrefix(ctx, prefix)
+		return err
+	})
+	return n, err
+}
+
+func (s *resilientStore) AddTags(ctx context.Context, items []TagItem) error {
+	return s.do(ctx, func(ctx context.Context) error {
+		return s.store.AddTags(ctx, items)
+	})
+}
+
+func (s *resilientStore) DeleteTagged(ctx context.Context, tagKeys []string) (keys []string, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		keys, err = s.store.DeleteTagged(ctx, tagKeys)
+		return err
+	})
+	return keys, err
+}
+
+func (s *resilientStore) Incr(ctx context.Context, key string) (n int64, err error) {
+	err = s.do(ctx, func(ctx context.Context) error {
+		n, err = s.store.Incr(ctx, key)
+		return err
+	})
+	return n, err
+}
+
+func (s *resilientStore) Ping(ctx context.Context) error {
+	return s.do(ctx, s.store.Ping)
+}
@@ -0,0 +1,112 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+	"github.com/bernardinorafael/gogem/pkg/logger"
+)
+
+// failingStore is a MemoryStore whose Get fails with err, or blocks until
+// its context is done when block is set.
+type failingStore struct {
+	*cache.MemoryStore
+	err   error
+	block bool
+}
+
+func (s *failingStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if s.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.MemoryStore.Get(ctx, key)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name     string
+		store    failingStore
+		opts     []func(*cache.Client)
+		timeout  time.Duration
+		wantOpen bool
+	}{
+		{
+			name:     "store failures open the circuit",
+			store:    failingStore{err: errors.New("connection refused")},
+			wantOpen: true,
+		},
+		{
+			name:     "operation timeouts open the circuit",
+			store:    failingStore{block: true},
+			opts:     []func(*cache.Client){cache.WithOperationTimeout(10 * time.Millisecond)},
+			wantOpen: true,
+		},
+		{
+			name:    "caller deadlines do not open the circuit",
+			store:   failingStore{block: true},
+			timeout: 10 * time.Millisecond,
+		},
+		{
+			name:  "misses do not open the circuit",
+			store: failingStore{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store
+			store.MemoryStore = cache.NewMemoryStore()
+			opts := append(tt.opts, cache.WithCircuitBreaker(cache.WithBreakerThreshold(2)))
+			client := cache.NewWithStore(&store, logger.New(logger.WithOutput(io.Discard)), opts...)
+
+			get := func() error {
+				ctx := context.Background()
+				if tt.timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+					defer cancel()
+				}
+				_, err := cache.Get[string](ctx, client, "key")
+				return err
+			}
+
+			for range 2 {
+				if err := get(); errors.Is(err, cache.ErrCircuitOpen) {
+					t.Fatalf("Get() error = %v before reaching the threshold", err)
+				}
+			}
+
+			if got := errors.Is(get(), cache.ErrCircuitOpen); got != tt.wantOpen {
+				t.Errorf("circuit open = %v, want %v", got, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestGetOrSetRecomputesUndecodableEntries(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient(cache.WithFailurePolicy(cache.FailClosed))
+	params := cache.SetParams{Client: client, Key: "count", TTL: time.Minute}
+
+	// Written with another type, e.g. before a change to the cached type.
+	if err := cache.Set(ctx, params, "not a number"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	value, err := cache.GetOrSet(ctx, params, func() (int, error) { return 42, nil })
+	if err != nil || value != 42 {
+		t.Fatalf("GetOrSet() = %d, %v, want 42", value, err)
+	}
+
+	if got, err := cache.Get[int](ctx, client, "count"); err != nil || got != 42 {
+		t.Errorf("Get() = %d, %v, want the recomputed 42", got, err)
+	}
+}
@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+type lockConfig struct {
+	prefix       string
+	ttl          time.Duration
+	wait         time.Duration
+	pollInterval time.Duration
+}
+
+// WithLockPrefix sets the prefix of the lock keys. Defaults to "lock:".
+func WithLockPrefix(prefix string) func(*lockConfig) {
+	return func(c *lockConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithLockTTL sets how long a lock is held at most, so a crashed instance
+// does not block recomputation forever. It should be longer than the
+// slowest callback. Defaults to 10s.
+func WithLockTTL(d time.Duration) func(*lockConfig) {
+	return func(c *lockConfig) {
+		c.ttl = d
+	}
+}
+
+// WithLockWait sets how long an instance waits for the lock holder to store
+// the value before computing it itself. Defaults to 5s.
+func WithLockWait(d time.Duration) func(*lockConfig) {
+	return func(c *lockConfig) {
+		c.wait = d
+	}
+}
+
+// WithLockPollInterval sets how often a waiting instance checks whether the
+// value has been stored. Defaults to 50ms.
+func WithLockPollInterval(d time.Duration) func(*lockConfig) {
+	return func(c *lockConfig) {
+		c.pollInterval = d
+	}
+}
+
+// WithLock makes GetOrSet acquire a Redis lock before running the callback
+// on a miss, so only one instance across the fleet recomputes a key while
+// the others wait for the value to be stored.
+//
+// Example:
+//
+//	client := cache.New(rdb, log, cache.WithLock(
+//	    cache.WithLockTTL(30*time.Second),
+//	    cache.WithLockWait(10*time.Second),
+//	))
+func WithLock(opts ...func(*lockConfig)) func(*Client) {
+	return func(c *Client) {
+		c.lock = &lockConfig{
+			prefix:       "lock:",
+			ttl:          10 * time.Second,
+			wait:         5 * time.Second,
+			pollInterval: 50 * time.Millisecond,
+		}
+
+		for _, fn := range opts {
+			fn(c.lock)
+		}
+	}
+}
+
+// WithWaitTimeout sets how long GetOrSet waits for a concurrent call in the
+// same process computing the same key before running the callback itself.
+// By default it waits until the computation finishes or the context is
+// done.
+func WithWaitTimeout(d time.Duration) func(*Client) {
+	return func(c *Client) {
+		c.waitTimeout = d
+	}
+}
+
+// load runs callback for a key that missed and stores its result.
+// Concurrent calls for the same key in the process share a single run, which
+// is detached from the cancellation of the call that started it; each call
+// stops waiting when its own context is done.
+func load[T any](ctx context.Context, params SetParams, callback func() (T, error)) (T, error) {
+	var zero T
+	c := params.Client
+
+	shared := context.WithoutCancel(ctx)
+	ch := c.flight.DoChan(params.Key, func() (any, error) {
+		return compute(shared, params, callback)
+	})
+
+	var timeout <-chan time.Time
+	if c.waitTimeout > 0 {
+		timer := time.NewTimer(c.waitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		if value, ok := res.Val.(T); ok {
+			return value, nil
+		}
+		// Another call used the same key with a different type.
+		return compute(ctx, params, callback)
+	case <-timeout:
+		c.log.Warn("timed out waiting for concurrent cache computation", "key", params.Key)
+		return compute(ctx, params, callback)
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// compute runs callback and stores its result, holding the distributed
+// lock when one is configured.
+func compute[T any](ctx context.Context, params SetParams, callback func() (T, error)) (T, error) {
+	c := params.Client
+	if c.lock == nil {
+		return computeAndSet(ctx, params, callback)
+	}
+
+	lockKey := c.lock.prefix + params.Key
+	token := lockToken()
+
+	acquired, err := c.store.SetNX(ctx, lockKey, []byte(token), c.lock.ttl)
+	if err != nil {
+		c.log.Warn("failed to acquire cache lock", "key", params.Key, "error", err)
+		return computeAndSet(ctx, params, callback)
+	}
+
+	if acquired {
+		defer func() {
+			// Release even if the request context was cancelled meanwhile,
+			// and only if the lock did not expire and get acquired by
+			// another instance.
+			releaseCtx := context.WithoutCancel(ctx)
+			if _, err := c.store.CompareAndDelete(releaseCtx, lockKey, []byte(token)); err != nil {
+				c.log.Warn("failed to release cache lock", "key", params.Key, "error", err)
+			}
+		}()
+		return computeAndSet(ctx, params, callback)
+	}
+
+	if value, ok, err := waitForValue[T](ctx, params); ok {
+		return value, err
+	}
+	if err := ctx.Err(); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	c.log.Warn("timed out waiting for cache lock", "key", params.Key)
+	return computeAndSet(ctx, params, callback)
+}
+
+// waitForValue polls the cache until the lock holder stores the value, or a
+// negatively cached fault, or the lock wait elapses.
+func waitForValue[T any](ctx context.Context, params SetParams) (T, bool, error) {
+	var zero T
+	c := params.Client
+
+	ticker := time.NewTicker(c.lock.pollInterval)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(c.lock.wait)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return zero, false, nil
+		case <-deadline.C:
+			return zero, false, nil
+		case <-ticker.C:
+			value, meta, err := get[T](ctx, c, params.Key, params.codec)
+			if err == nil || meta.Negative {
+				return value, true, err
+			}
+		}
+	}
+}
+
+func computeAndSet[T any](ctx context.Context, params SetParams, callback func() (T, error)) (T, error) {
+	start := time.Now()
+	value, err := callback()
+	if err != nil {
+		if f, ok := negativeFault(params, err); ok {
+			if err := setNegative(ctx, params, f, time.Since(start)); err != nil {
+				params.Client.log.Warn("failed to save to cache", "key", params.Key, "error", err)
+			}
+		}
+		return value, err
+	}
+
+	if err := set(ctx, params, value, time.Since(start)); err != nil {
+		if params.Client.failClosed() {
+			var zero T
+			return zero, err
+		}
+		params.Client.log.Warn("failed to save to cache", "key", params.Key, "error", err)
+	}
+
+	return value, nil
+}
+
+// refresh recomputes a stale or soon to expire value in the background.
+// Failures are logged and the cached value is left in place. Refreshes of
+// the same key are shared with concurrent loads in the process.
+func refresh[T any](ctx context.Context, params SetParams, callback func() (T, error)) {
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		res := <-params.Client.flight.DoChan(params.Key, func() (any, error) {
+			return compute(ctx, params, callback)
+		})
+		if res.Err != nil {
+			params.Client.log.Warn("failed to refresh cache entry", "key", params.Key, "error", res.Err)
+		}
+	}()
+}
+
+func lockToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,126 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+	"github.com/bernardinorafael/gogem/pkg/logger"
+)
+
+func newTestClient(opts ...func(*cache.Client)) (*cache.Client, *cache.MemoryStore) {
+	store := cache.NewMemoryStore()
+	return newStoreClient(store, opts...), store
+}
+
+// newStoreClient returns a client over store, e.g. to share one store
+// between clients configured differently.
+func newStoreClient(store cache.Store, opts ...func(*cache.Client)) *cache.Client {
+	return cache.NewWithStore(store, logger.New(logger.WithOutput(io.Discard)), opts...)
+}
+
+func TestGetOrSetRunsCallbackOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []func(*cache.Client)
+	}{
+		{name: "singleflight"},
+		{name: "distributed lock", opts: []func(*cache.Client){cache.WithLock()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(tt.opts...)
+			params := cache.SetParams{Client: client, Key: "hot", TTL: time.Minute}
+
+			var calls atomic.Int32
+			callback := func() (string, error) {
+				calls.Add(1)
+				time.Sleep(50 * time.Millisecond)
+				return "value", nil
+			}
+
+			const n = 50
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					value, err := cache.GetOrSet(context.Background(), params, callback)
+					if err != nil || value != "value" {
+						t.Errorf("GetOrSet() = %q, %v, want %q", value, err, "value")
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if got := calls.Load(); got != 1 {
+				t.Errorf("callback ran %d times, want 1", got)
+			}
+		})
+	}
+}
+
+func TestGetOrSetSharedLoadOutlivesCancelledCaller(t *testing.T) {
+	client, _ := newTestClient()
+	params := cache.SetParams{Client: client, Key: "shared", TTL: time.Minute}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cache.GetOrSet(ctx, params, func() (string, error) {
+			calls.Add(1)
+			close(started)
+			<-release
+			return "value", nil
+		})
+		firstErr <- err
+	}()
+	<-started
+
+	type result struct {
+		value string
+		err   error
+	}
+	second := make(chan result, 1)
+	go func() {
+		value, err := cache.GetOrSet(context.Background(), params, func() (string, error) {
+			calls.Add(1)
+			return "value", nil
+		})
+		second <- result{value, err}
+	}()
+
+	// Let the second call join the load before the first one gives up.
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled GetOrSet() error = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	res := <-second
+	if res.err != nil || res.value != "value" {
+		t.Fatalf("waiting GetOrSet() = %q, %v, want %q", res.value, res.err, "value")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("callback ran %d times, want 1", got)
+	}
+
+	cached, err := cache.Get[string](context.Background(), client, "shared")
+	if err != nil || cached != "value" {
+		t.Errorf("Get() = %q, %v, want the value stored by the shared load", cached, err)
+	}
+}
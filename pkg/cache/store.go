@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Store implementations when a key does not
+// exist or has expired.
+var ErrNotFound = errors.New("cache: key not found")
+
+// Store is the storage backend of a Client. Values are opaque bytes; the
+// Client takes care of serialization and metadata.
+type Store interface {
+	// Get returns the value stored under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// GetMany returns the values stored under keys in the same order, with
+	// nil for missing keys.
+	GetMany(ctx context.Context, keys []string) ([][]byte, error)
+	// Set stores value under key. A ttl of zero means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetMany stores every item, each with its own TTL.
+	SetMany(ctx context.Context, items []StoreItem) error
+	// SetNX stores value under key only if the key does not exist, and
+	// reports whether it was stored.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// CompareAndDelete deletes key only if it holds value, and reports
+	// whether it was deleted.
+	CompareAndDelete(ctx context.Context, key string, value []byte) (bool, error)
+	// Delete removes keys. Missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+	// DeletePrefix removes every key starting with prefix, without blocking
+	// the store, and returns how many were removed.
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+	// AddTags adds the keys of every item to the sets stored under its tag
+	// keys, in a single round trip.
+	AddTags(ctx context.Context, items []TagItem) error
+	// DeleteTagged atomically removes the keys in the sets stored under
+	// tagKeys along with the sets, and returns the removed keys.
+	DeleteTagged(ctx context.Context, tagKeys []string) ([]string, error)
+	// Incr increments the integer stored under key, starting from zero,
+	// and returns the new value.
+	Incr(ctx context.Context, key string) (int64, error)
+	// Ping checks that the store is reachable.
+	Ping(ctx context.Context) error
+}
+
+// StoreItem is a value written by Store.SetMany.
+type StoreItem struct {
+	Key   string
+	Value []byte
+	TTL   time.Duration
+}
+
+// TagItem is a tagging written by Store.AddTags: Keys are added to the sets
+// stored under TagKeys, which live at least TTL (forever if TTL is zero).
+type TagItem struct {
+	Keys    []string
+	TagKeys []string
+	TTL     time.Duration
+}
+
+// Broadcaster is implemented by stores that can deliver messages to every
+// Client sharing them. The local tier (see WithLocalCache) uses it to
+// evict copies on other instances; without it invalidations stay local.
+type Broadcaster interface {
+	// Publish sends payload to the subscribers of channel.
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe calls onMessage with every payload published on channel
+	// and onReset whenever the subscription is (re)established, since
+	// messages sent while disconnected are lost. It stops when the
+	// returned function is called.
+	Subscribe(channel string, onMessage func(payload []byte), onReset func()) (unsubscribe func() error)
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+// WithTagPrefix sets the prefix of the keys holding the members of each tag.
+// Defaults to "tag:".
+func WithTagPrefix(prefix string) func(*Client) {
+	return func(c *Client) {
+		c.tagPrefix = prefix
+	}
+}
+
+// InvalidateTags atomically deletes every entry written with one of tags
+// (see SetParams.Tags), and evicts them from the local tier of every
+// instance.
+//
+// On a Redis cluster or ring the tagged keys may live on any node; the
+// deletion is then not atomic, and entries tagged while it runs may be
+// kept.
+//
+// Example:
+//
+//	_, err := cache.GetOrSet(ctx, cache.SetParams{
+//	    Client: client,
+//	    Key:    "user:" + id,
+//	    TTL:    time.Hour,
+//	    Tags:   []string{"org:" + orgID},
+//	}, loadUser)
+//
+//	err := cache.InvalidateTags(ctx, client, "org:"+orgID)
+func InvalidateTags(ctx context.Context, c *Client, tags ...string) error {
+	ctx, finish := c.observe(ctx, OpInvalidateTags, strings.Join(tags, ","))
+
+	deleted, err := c.store.DeleteTagged(ctx, c.tagKeys(tags))
+	if err != nil {
+		err = fault.New("failed to invalidate cache tags", fault.WithTag(fault.DB), fault.WithErr(err))
+		finish(OutcomeError, err)
+		return err
+	}
+
+	c.invalidate(ctx, deleted...)
+
+	finish(OutcomeOK, nil)
+	return nil
+}
+
+// DeletePrefix deletes every entry whose key starts with prefix and returns
+// how many were deleted. It walks the keyspace with SCAN in batches rather
+// than KEYS, so it does not block Redis, but it is proportional to the size
+// of the keyspace and entries written meanwhile may survive. An empty prefix
+// is rejected.
+func DeletePrefix(ctx context.Context, c *Client, prefix string) (int, error) {
+	ctx, finish := c.observe(ctx, OpDeletePrefix, prefix)
+
+	if prefix == "" {
+		err := fault.NewBadRequest("cache prefix must not be empty")
+		finish(OutcomeError, err)
+		return 0, err
+	}
+
+	deleted, err := c.store.DeletePrefix(ctx, prefix)
+	if err != nil {
+		err = fault.New("failed to delete from cache", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+
+	// Some keys may have been deleted even if the walk failed.
+	c.invalidatePrefix(ctx, prefix)
+
+	finish(outcomeOf(err), err)
+	return deleted, err
+}
+
+// tag adds keys to the sets of tags. It runs before the entries are written
+// so an invalidation never misses an entry that is stored.
+func (c *Client) tag(ctx context.Context, keys []string, tags []string, ttl time.Duration) error {
+	return c.tagMany(ctx, []TagItem{{Keys: keys, TagKeys: tags, TTL: ttl}})
+}
+
+// tagMany is like tag for several taggings at once, in a single round trip.
+// The TagKeys of items hold tag names, which it turns into keys.
+func (c *Client) tagMany(ctx context.Context, items []TagItem) error {
+	batch := make([]TagItem, 0, len(items))
+	for _, item := range items {
+		if len(item.Keys) == 0 || len(item.TagKeys) == 0 {
+			continue
+		}
+		item.TagKeys = c.tagKeys(item.TagKeys)
+		batch = append(batch, item)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := c.store.AddTags(ctx, batch); err != nil {
+		return fault.New("failed to tag cache entries", fault.WithTag(fault.DB), fault.WithErr(err))
+	}
+	return nil
+}
+
+func (c *Client) tagKeys(tags []string) []string {
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = c.tagPrefix + tag
+	}
+	return keys
+}
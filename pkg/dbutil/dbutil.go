@@ -62,7 +62,11 @@ func VerifyForeignKeyViolation(target error) (string, error) {
 	return "", nil
 }
 
-func ExecTx(ctx context.Context, db *sqlx.DB, fn func(*sqlx.Tx) error) error {
+func ExecTx(ctx context.Context, db *sqlx.DB, fn func(*sqlx.Tx) error) (err error) {
+	ctx, finish := observe(ctx)
+	outcome := OutcomeError
+	defer func() { finish(outcome, err) }()
+
 	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fault.New("failed to begin transaction", fault.WithTag(fault.TX), fault.WithErr(err))
@@ -72,6 +76,7 @@ func ExecTx(ctx context.Context, db *sqlx.DB, fn func(*sqlx.Tx) error) error {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return fault.New("failed to rollback transaction", fault.WithTag(fault.TX), fault.WithErr(rollbackErr))
 		}
+		outcome = OutcomeRollback
 		return fault.New("transaction failed", fault.WithTag(fault.TX), fault.WithErr(err))
 	}
 
@@ -79,5 +84,6 @@ func ExecTx(ctx context.Context, db *sqlx.DB, fn func(*sqlx.Tx) error) error {
 		return fault.New("failed to commit transaction", fault.WithTag(fault.TX), fault.WithErr(err))
 	}
 
+	outcome = OutcomeCommit
 	return nil
 }
@@ -28,6 +28,11 @@
 //	    return err
 //	})
 //
+// Observers registered once at startup are notified around every ExecTx call
+// with its outcome (commit, rollback or error), e.g. to record metrics:
+//
+//	dbutil.RegisterObserver(m.DBObserver())
+//
 // JSONB type for PostgreSQL jsonb columns:
 //
 //	type User struct {
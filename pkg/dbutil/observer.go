@@ -0,0 +1,56 @@
+package dbutil
+
+import (
+	"context"
+	"sync"
+)
+
+// Outcomes reported to observers when a transaction finishes.
+const (
+	OutcomeCommit   = "commit"
+	OutcomeRollback = "rollback"
+	OutcomeError    = "error"
+)
+
+// Observer is notified around transactions run by ExecTx, e.g. to record
+// metrics or traces. Start is called before the transaction begins and
+// returns the context the transaction runs with, along with a function
+// called with OutcomeCommit, OutcomeRollback or OutcomeError once it finishes.
+type Observer interface {
+	Start(ctx context.Context) (context.Context, func(outcome string, err error))
+}
+
+var (
+	observersMu sync.RWMutex
+	observers   []Observer
+)
+
+// RegisterObserver registers an observer for every ExecTx call. It is meant
+// to be called once during application startup.
+func RegisterObserver(o Observer) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+
+	observers = append(observers, o)
+}
+
+func observe(ctx context.Context) (context.Context, func(outcome string, err error)) {
+	observersMu.RLock()
+	registered := observers
+	observersMu.RUnlock()
+
+	if len(registered) == 0 {
+		return ctx, func(string, error) {}
+	}
+
+	finishers := make([]func(string, error), len(registered))
+	for i, o := range registered {
+		ctx, finishers[i] = o.Start(ctx)
+	}
+
+	return ctx, func(outcome string, err error) {
+		for i := len(finishers) - 1; i >= 0; i-- {
+			finishers[i](outcome, err)
+		}
+	}
+}
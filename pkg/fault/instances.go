@@ -76,3 +76,19 @@ func NewUnprocessableEntity(message string, options ...func(*Fault)) *Fault {
 	}
 	return New(message, append(defaults, options...)...)
 }
+
+func NewServiceUnavailable(message string, options ...func(*Fault)) *Fault {
+	defaults := []func(*Fault){
+		WithHTTPCode(http.StatusServiceUnavailable),
+		WithTag(ServiceUnavailable),
+	}
+	return New(message, append(defaults, options...)...)
+}
+
+func NewGatewayTimeout(message string, options ...func(*Fault)) *Fault {
+	defaults := []func(*Fault){
+		WithHTTPCode(http.StatusGatewayTimeout),
+		WithTag(GatewayTimeout),
+	}
+	return New(message, append(defaults, options...)...)
+}
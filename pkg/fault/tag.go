@@ -15,6 +15,8 @@ const (
 	TooManyRequests     Tag = "TOO_MANY_REQUESTS"
 	ValidationError     Tag = "VALIDATION"
 	UnprocessableEntity Tag = "UNPROCESSABLE_ENTITY"
+	ServiceUnavailable  Tag = "SERVICE_UNAVAILABLE"
+	GatewayTimeout      Tag = "GATEWAY_TIMEOUT"
 	DB                  Tag = "DATABASE"
 	TX                  Tag = "DB_TRANSACTION"
 )
@@ -0,0 +1,17 @@
+// Package http3 serves HTTP/3 over QUIC alongside a server.Server. It lives
+// in its own module so services that do not need HTTP/3 do not depend on
+// quic-go.
+//
+// The HTTP/3 server listens on the same port (UDP) as the HTTPS server,
+// shares its handler and TLS configuration, and is advertised to clients
+// through the Alt-Svc header on TCP responses:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithPort(443),
+//	    server.WithTLS(certFile, keyFile),
+//	    server.WithProtocol(http3.New()),
+//	)
+//
+// TLS is required; Serve fails when the server has no TLS configuration.
+package http3
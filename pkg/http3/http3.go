@@ -0,0 +1,71 @@
+package http3
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// Server serves HTTP/3 next to an HTTPS server. It implements
+// server.Protocol.
+type Server struct {
+	mu     sync.Mutex
+	server *http3.Server
+}
+
+func New() *Server {
+	return &Server{}
+}
+
+// Serve serves srv.Handler over HTTP/3 on the UDP port of addr in the
+// background, and returns a handler advertising HTTP/3 through Alt-Svc for
+// the HTTPS server to use.
+func (s *Server) Serve(addr string, srv *http.Server) (http.Handler, error) {
+	if srv.TLSConfig == nil {
+		return nil, errors.New("http3: HTTP/3 requires TLS")
+	}
+
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	h3 := &http3.Server{
+		Handler:        srv.Handler,
+		TLSConfig:      http3.ConfigureTLSConfig(srv.TLSConfig),
+		IdleTimeout:    srv.IdleTimeout,
+		MaxHeaderBytes: srv.MaxHeaderBytes,
+	}
+
+	s.mu.Lock()
+	s.server = h3
+	s.mu.Unlock()
+
+	go func() {
+		_ = h3.Serve(conn)
+		_ = conn.Close()
+	}()
+
+	next := srv.Handler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = h3.SetQUICHeaders(w.Header())
+		next.ServeHTTP(w, r)
+	}), nil
+}
+
+// Shutdown gracefully stops the HTTP/3 server. It does nothing if Serve
+// was not called.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	h3 := s.server
+	s.mu.Unlock()
+
+	if h3 == nil {
+		return nil
+	}
+	return h3.Shutdown(ctx)
+}
@@ -60,23 +60,39 @@ type Validator interface {
 func WithValidation[T Validator](done http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var body T
-		if err := ReadRequestBody(r, &body); err != nil {
+		if err := readBody(r, &body); err != nil {
 			WriteError(w, err)
 			return
 		}
 
-		if err := body.Validate(); err != nil {
-			WriteError(w, fault.New(
-				err.Error(),
-				fault.WithHTTPCode(http.StatusUnprocessableEntity),
-				fault.WithTag(fault.ValidationError),
-			))
-			return
-		}
-
 		ctx := context.WithValue(r.Context(), contextKey{}, body)
 		r = r.WithContext(ctx)
 
 		done(w, r)
 	}
 }
+
+// readBody decodes the request body into dst and validates it when T, or a
+// pointer to it, implements Validator.
+func readBody[T any](r *http.Request, dst *T) error {
+	if err := ReadRequestBody(r, dst); err != nil {
+		return err
+	}
+
+	v, ok := any(*dst).(Validator)
+	if !ok {
+		if v, ok = any(dst).(Validator); !ok {
+			return nil
+		}
+	}
+
+	if err := v.Validate(); err != nil {
+		return fault.New(
+			err.Error(),
+			fault.WithHTTPCode(http.StatusUnprocessableEntity),
+			fault.WithTag(fault.ValidationError),
+		)
+	}
+
+	return nil
+}
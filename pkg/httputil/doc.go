@@ -35,6 +35,31 @@
 //	_ = httputil.WriteSuccess(w, http.StatusCreated)
 //	httputil.WriteError(w, err)
 //
+// Typed handler adapters decode and validate the request, call a function
+// returning the response or an error, and write either with WriteJSON or
+// WriteError:
+//
+//	router.Post("/users", httputil.Handle(func(ctx context.Context, dto CreateUserDTO) (User, error) {
+//	    return users.Create(ctx, dto)
+//	}, httputil.WithStatus(http.StatusCreated)))
+//
+//	router.Get("/users/{id}", httputil.HandleNoBody(func(ctx context.Context) (User, error) {
+//	    return users.Get(ctx, httputil.PathValue(ctx, "id"))
+//	}))
+//
+// HandleNoResponse and HandleEmpty cover handlers without a response body
+// and respond with 204 No Content by default. The request is available to
+// the function through RequestFromContext.
+//
+// Middleware reporting the matched route calls TrackRoute before passing the
+// request on and Route once the handler returns. RecordRoute wraps the router
+// so the pattern reaches them even when middleware in between copied the
+// request with r.WithContext; server.New applies it automatically.
+//
+// Middleware that needs to know which error a handler failed with, such as
+// tracing, can wrap the ResponseWriter with a type implementing ErrorRecorder;
+// WriteError reports the error to it before writing the response.
+//
 // Generic validation middleware using WithValidation[T] and GetBody[T]:
 //
 //	type CreateUserDTO struct {
@@ -0,0 +1,149 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+)
+
+type requestKey struct{}
+
+type handlerConfig struct {
+	status int
+}
+
+// WithStatus sets the status written when the handler succeeds. Defaults to
+// 200 OK for handlers returning a response and 204 No Content otherwise.
+func WithStatus(code int) func(*handlerConfig) {
+	return func(c *handlerConfig) {
+		c.status = code
+	}
+}
+
+// Handle adapts fn to an http.HandlerFunc. The request body is decoded into
+// Req with ReadRequestBody and validated when Req implements Validator; the
+// Res returned by fn is written with WriteJSON, and any error, including
+// decoding and validation errors, is written with WriteError.
+//
+// The request is available to fn through RequestFromContext and PathValue.
+//
+// Example:
+//
+//	router.Post("/users", httputil.Handle(func(ctx context.Context, dto CreateUserDTO) (User, error) {
+//	    return users.Create(ctx, dto)
+//	}, httputil.WithStatus(http.StatusCreated)))
+func Handle[Req, Res any](fn func(context.Context, Req) (Res, error), opts ...func(*handlerConfig)) http.HandlerFunc {
+	cfg := newHandlerConfig(http.StatusOK, opts)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if err := readBody(r, &req); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		res, err := fn(requestContext(r), req)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		_ = WriteJSON(w, cfg.status, res)
+	}
+}
+
+// HandleNoBody is like Handle for requests without a body, such as GET and
+// DELETE requests. Path and query parameters are read through
+// RequestFromContext and PathValue.
+//
+// Example:
+//
+//	router.Get("/users/{id}", httputil.HandleNoBody(func(ctx context.Context) (User, error) {
+//	    return users.Get(ctx, httputil.PathValue(ctx, "id"))
+//	}))
+func HandleNoBody[Res any](fn func(context.Context) (Res, error), opts ...func(*handlerConfig)) http.HandlerFunc {
+	cfg := newHandlerConfig(http.StatusOK, opts)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := fn(requestContext(r))
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		_ = WriteJSON(w, cfg.status, res)
+	}
+}
+
+// HandleNoResponse is like Handle for handlers that respond without a body.
+// It writes 204 No Content on success unless WithStatus is given.
+func HandleNoResponse[Req any](fn func(context.Context, Req) error, opts ...func(*handlerConfig)) http.HandlerFunc {
+	cfg := newHandlerConfig(http.StatusNoContent, opts)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if err := readBody(r, &req); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		if err := fn(requestContext(r), req); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.WriteHeader(cfg.status)
+	}
+}
+
+// HandleEmpty adapts a handler taking no request body and responding
+// without a body. It writes 204 No Content on success unless WithStatus is
+// given.
+//
+// Example:
+//
+//	router.Delete("/users/{id}", httputil.HandleEmpty(func(ctx context.Context) error {
+//	    return users.Delete(ctx, httputil.PathValue(ctx, "id"))
+//	}))
+func HandleEmpty(fn func(context.Context) error, opts ...func(*handlerConfig)) http.HandlerFunc {
+	cfg := newHandlerConfig(http.StatusNoContent, opts)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(requestContext(r)); err != nil {
+			WriteError(w, err)
+			return
+		}
+
+		w.WriteHeader(cfg.status)
+	}
+}
+
+// RequestFromContext returns the request being handled by one of the Handle
+// adapters, or nil if ctx does not come from one.
+func RequestFromContext(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestKey{}).(*http.Request)
+	return r
+}
+
+// PathValue returns the value of the named path wildcard of the request
+// being handled by one of the Handle adapters, as matched by the standard
+// library ServeMux. It returns an empty string if there is none.
+func PathValue(ctx context.Context, name string) string {
+	if r := RequestFromContext(ctx); r != nil {
+		return r.PathValue(name)
+	}
+	return ""
+}
+
+func newHandlerConfig(status int, opts []func(*handlerConfig)) handlerConfig {
+	cfg := handlerConfig{status: status}
+
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+
+	return cfg
+}
+
+func requestContext(r *http.Request) context.Context {
+	return context.WithValue(r.Context(), requestKey{}, r)
+}
@@ -16,7 +16,16 @@ import (
 
 const maxRequestBodyBytes = 1_048_576 // 1MB
 
+// ErrorRecorder is implemented by response writers that observe the errors
+// written by WriteError, such as tracing or logging middleware. WriteError
+// looks for it through writers exposing Unwrap() http.ResponseWriter.
+type ErrorRecorder interface {
+	RecordError(err error)
+}
+
 func WriteError(w http.ResponseWriter, err error) {
+	recordError(w, err)
+
 	w.Header().Set("Content-Type", "application/json")
 
 	var f *fault.Fault
@@ -30,6 +39,20 @@ func WriteError(w http.ResponseWriter, err error) {
 	_ = json.NewEncoder(w).Encode(fault.NewInternalServerError("an unexpected error occurred"))
 }
 
+func recordError(w http.ResponseWriter, err error) {
+	for w != nil {
+		if er, ok := w.(ErrorRecorder); ok {
+			er.RecordError(err)
+			return
+		}
+		uw, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = uw.Unwrap()
+	}
+}
+
 // WriteSuccess writes a JSON success response with the specified HTTP status code.
 func WriteSuccess(w http.ResponseWriter, code int) error {
 	w.Header().Set("Content-Type", "application/json")
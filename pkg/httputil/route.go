@@ -0,0 +1,96 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type routeKey struct{}
+
+// route holds the pattern matched for a request. It is shared through the
+// request context, so it survives the request copies made by r.WithContext
+// between the middleware reading it and the router.
+type route struct {
+	mu      sync.Mutex
+	pattern string
+}
+
+func (rt *route) set(pattern string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.pattern = pattern
+}
+
+func (rt *route) get() string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.pattern
+}
+
+// TrackRoute returns r with a place in its context for RecordRoute to store
+// the pattern matched by the router, to be read with Route. It returns r
+// unchanged if it already has one. Middleware reporting the route, such as
+// metrics and tracing, call it before passing the request on.
+func TrackRoute(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(routeKey{}).(*route); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, &route{}))
+}
+
+// RecordRoute wraps a router so the pattern it matches is visible to the
+// middleware that called TrackRoute, whichever request copy they hold. With a
+// *http.ServeMux the pattern is recorded before the handler runs; with other
+// routers setting r.Pattern it is recorded once they return. server.New
+// applies it to the configured handler.
+//
+// Example:
+//
+//	handler := httputil.RecordRoute(mux)
+//	handler = m.HTTPMiddleware()(handler)
+func RecordRoute(next http.Handler) http.Handler {
+	mux, isMux := next.(*http.ServeMux)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rt, ok := r.Context().Value(routeKey{}).(*route)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if isMux {
+			_, pattern := mux.Handler(r)
+			rt.set(pattern)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+
+		// A nested RecordRoute closer to the handler may already have
+		// recorded a more specific pattern.
+		if r.Pattern != "" && rt.get() == "" {
+			rt.set(r.Pattern)
+		}
+	})
+}
+
+// Route returns the route matched for r without its method, e.g.
+// "/users/{id}" for "GET /users/{id}". It reads the pattern recorded by
+// RecordRoute, falling back to r.Pattern, and returns "" when no route
+// matched yet.
+func Route(r *http.Request) string {
+	pattern := r.Pattern
+	if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+		if p := rt.get(); p != "" {
+			pattern = p
+		}
+	}
+
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
@@ -52,6 +52,17 @@
 // FromContext returns charmbracelet/log's default logger if no logger is found
 // in the context, so it is always safe to call without nil checks.
 //
+// Fields that can be derived from the context itself, such as trace and span
+// IDs, can be added to every logger returned by FromContext by registering a
+// function once at startup:
+//
+//	logger.RegisterContextFields(func(ctx context.Context) []any {
+//	    if id := tenant.FromContext(ctx); id != "" {
+//	        return []any{"tenant_id", id}
+//	    }
+//	    return nil
+//	})
+//
 // # Logging
 //
 //	log.Info("user created", "id", userID)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/log"
@@ -11,6 +12,11 @@ import (
 
 type loggerKey struct{}
 
+var (
+	contextFieldsMu sync.RWMutex
+	contextFields   []func(context.Context) []any
+)
+
 func New(opts ...func(*config)) Logger {
 	cfg := config{
 		output:      os.Stdout,
@@ -86,9 +92,62 @@ func WithContext(ctx context.Context, l Logger) context.Context {
 
 // FromContext retrieves the logger from the context.
 // Returns the default logger (production, info level) if none is found.
+// The fields returned by the functions registered with RegisterContextFields
+// are added to the returned logger, provided it has a With method returning
+// a *log.Logger (as the loggers created by New do) or a Logger. Other Logger
+// implementations are returned as is, without the fields.
 func FromContext(ctx context.Context) Logger {
-	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	if !ok {
+		l = log.Default()
+	}
+	return withContextFields(ctx, l)
+}
+
+// RegisterContextFields registers fn to add key-value pairs derived from the
+// context, such as trace and span IDs, to every logger returned by
+// FromContext. fn returns nil when the context carries nothing to add. It is
+// meant to be called once during application startup. The fields are only
+// added to loggers that support With, see FromContext.
+func RegisterContextFields(fn func(ctx context.Context) []any) {
+	contextFieldsMu.Lock()
+	defer contextFieldsMu.Unlock()
+
+	contextFields = append(contextFields, fn)
+}
+
+// fieldLogger is implemented by loggers that can derive a logger with
+// additional fields, such as *log.Logger.
+type fieldLogger interface {
+	With(keyvals ...any) *log.Logger
+}
+
+// withLogger is implemented by custom loggers that can derive a logger with
+// additional fields.
+type withLogger interface {
+	With(keyvals ...any) Logger
+}
+
+func withContextFields(ctx context.Context, l Logger) Logger {
+	contextFieldsMu.RLock()
+	registered := contextFields
+	contextFieldsMu.RUnlock()
+
+	var keyvals []any
+	for _, fn := range registered {
+		keyvals = append(keyvals, fn(ctx)...)
+	}
+	if len(keyvals) == 0 {
+		return l
+	}
+
+	switch wl := l.(type) {
+	case fieldLogger:
+		return wl.With(keyvals...)
+	case withLogger:
+		return wl.With(keyvals...)
+	default:
+		// The logger cannot carry extra fields; they are dropped.
 		return l
 	}
-	return log.Default()
 }
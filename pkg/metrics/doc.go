@@ -0,0 +1,38 @@
+// Package metrics exposes Prometheus metrics for HTTP requests and for the
+// cache, queue and dbutil packages.
+//
+// Creating the collectors and serving them from the server:
+//
+//	m, err := metrics.New(metrics.WithNamespace("orders"))
+//	if err != nil {
+//	    return err
+//	}
+//
+//	srv := server.New(
+//	    server.WithHandler(mux),
+//	    server.WithMiddleware(m.HTTPMiddleware()),
+//	    server.WithMetricsHandler(m.Handler()),
+//	)
+//
+// HTTPMiddleware records http_requests_total and
+// http_request_duration_seconds by method, route and status, and
+// http_requests_in_flight. The route label is the matched pattern (the path
+// of r.Pattern recorded by httputil.RecordRoute by default, see WithRouteFunc
+// for other routers); requests matching no route are labeled "unmatched".
+//
+// Instrumenting the other packages through their observers:
+//
+//	cacheClient := cache.New(rdb, log, cache.WithObserver(m.CacheObserver()))
+//	queueClient, err := queue.NewClient(ctx, cfg, queue.WithObserver(m.QueueObserver()))
+//	dbutil.RegisterObserver(m.DBObserver())
+//
+// This records cache_operations_total and cache_operation_duration_seconds by
+// operation and outcome (the GetOrSet hit ratio is
+// hit / (hit + miss) for op="get_or_set"), queue_operations_total and
+// queue_operation_duration_seconds by operation and outcome, and
+// db_transactions_total and db_transaction_duration_seconds by outcome
+// (commit, rollback or error).
+//
+// Collectors are registered on the Prometheus default registry unless
+// WithRegistry is given.
+package metrics
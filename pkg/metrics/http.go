@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/httputil"
+)
+
+// unmatchedRoute labels requests that did not match any route, so unknown
+// paths do not create a new series each.
+const unmatchedRoute = "unmatched"
+
+type httpConfig struct {
+	route func(*http.Request) string
+}
+
+// WithRouteFunc sets how the route label is derived from a request. It is
+// called after the handler returns. Defaults to httputil.Route, the pattern
+// matched by the standard library ServeMux.
+//
+// Example with chi:
+//
+//	m.HTTPMiddleware(metrics.WithRouteFunc(func(r *http.Request) string {
+//	    return chi.RouteContext(r.Context()).RoutePattern()
+//	}))
+func WithRouteFunc(fn func(*http.Request) string) func(*httpConfig) {
+	return func(c *httpConfig) {
+		c.route = fn
+	}
+}
+
+// HTTPMiddleware returns a middleware recording the number, duration and
+// status of requests by method and route, and the number of requests in
+// flight. The route is the matched pattern rather than the raw path, to keep
+// the number of series bounded. When the handler is not served by
+// server.Server, wrap the router with httputil.RecordRoute so the pattern is
+// known even if middleware in between copies the request.
+func (m *Metrics) HTTPMiddleware(opts ...func(*httpConfig)) func(http.Handler) http.Handler {
+	cfg := httpConfig{
+		route: httputil.Route,
+	}
+
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.httpInFlight.Inc()
+			defer m.httpInFlight.Dec()
+
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			r = httputil.TrackRoute(r)
+
+			next.ServeHTTP(sw, r)
+
+			route := cfg.route(r)
+			if route == "" {
+				route = unmatchedRoute
+			}
+			status := strconv.Itoa(sw.status)
+
+			m.httpRequests.WithLabelValues(r.Method, route, status).Inc()
+			m.httpDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// statusWriter captures the status code written by the handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.status = code
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(b)
+}
+
+func (sw *statusWriter) Flush() {
+	_ = http.NewResponseController(sw.ResponseWriter).Flush()
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(sw.ResponseWriter).Hijack()
+}
@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	outcomeOK    = "ok"
+	outcomeError = "error"
+)
+
+// Metrics holds the Prometheus collectors shared by the HTTP middleware and
+// the cache, queue and database observers.
+type Metrics struct {
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+	namespace  string
+	buckets    []float64
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	httpInFlight  prometheus.Gauge
+	cacheOps      *prometheus.CounterVec
+	cacheDuration *prometheus.HistogramVec
+	queueOps      *prometheus.CounterVec
+	queueDuration *prometheus.HistogramVec
+	txTotal       *prometheus.CounterVec
+	txDuration    *prometheus.HistogramVec
+}
+
+// WithRegistry registers the collectors on reg and serves it from Handler
+// instead of the Prometheus default registry.
+func WithRegistry(reg *prometheus.Registry) func(*Metrics) {
+	return func(m *Metrics) {
+		m.registerer = reg
+		m.gatherer = reg
+	}
+}
+
+// WithNamespace prefixes every metric name with ns, e.g. "orders" produces
+// orders_http_requests_total.
+func WithNamespace(ns string) func(*Metrics) {
+	return func(m *Metrics) {
+		m.namespace = ns
+	}
+}
+
+// WithBuckets sets the histogram buckets, in seconds, used for every
+// duration metric. Defaults to prometheus.DefBuckets.
+func WithBuckets(buckets []float64) func(*Metrics) {
+	return func(m *Metrics) {
+		m.buckets = buckets
+	}
+}
+
+// New creates and registers the collectors. Calling New more than once with
+// the same registry and namespace reuses the collectors already registered.
+// It returns an error when a collector cannot be registered, e.g. because a
+// different collector with the same name already is.
+//
+// Example:
+//
+//	m, err := metrics.New(metrics.WithNamespace("orders"))
+//	cacheClient := cache.New(rdb, log, cache.WithObserver(m.CacheObserver()))
+//	dbutil.RegisterObserver(m.DBObserver())
+func New(opts ...func(*Metrics)) (*Metrics, error) {
+	m := Metrics{
+		registerer: prometheus.DefaultRegisterer,
+		gatherer:   prometheus.DefaultGatherer,
+		buckets:    prometheus.DefBuckets,
+	}
+
+	for _, fn := range opts {
+		fn(&m)
+	}
+
+	var errs []error
+
+	m.httpRequests = register(m.registerer, &errs, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"}))
+
+	m.httpDuration = register(m.registerer, &errs, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by method, route and status code.",
+		Buckets:   m.buckets,
+	}, []string{"method", "route", "status"}))
+
+	m.httpInFlight = register(m.registerer, &errs, prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: m.namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	}))
+
+	m.cacheOps = register(m.registerer, &errs, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: "cache",
+		Name:      "operations_total",
+		Help:      "Total number of cache operations by operation and outcome (hit, miss, ok, error).",
+	}, []string{"op", "outcome"}))
+
+	m.cacheDuration = register(m.registerer, &errs, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: "cache",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of cache operations by operation and outcome.",
+		Buckets:   m.buckets,
+	}, []string{"op", "outcome"}))
+
+	m.queueOps = register(m.registerer, &errs, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: "queue",
+		Name:      "operations_total",
+		Help:      "Total number of queue operations by operation and outcome (ok, error).",
+	}, []string{"op", "outcome"}))
+
+	m.queueDuration = register(m.registerer, &errs, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: "queue",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of queue operations by operation and outcome.",
+		Buckets:   m.buckets,
+	}, []string{"op", "outcome"}))
+
+	m.txTotal = register(m.registerer, &errs, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: "db",
+		Name:      "transactions_total",
+		Help:      "Total number of database transactions by outcome (commit, rollback, error).",
+	}, []string{"outcome"}))
+
+	m.txDuration = register(m.registerer, &errs, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: "db",
+		Name:      "transaction_duration_seconds",
+		Help:      "Duration of database transactions by outcome.",
+		Buckets:   m.buckets,
+	}, []string{"outcome"}))
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Handler returns the handler exposing the registry in the Prometheus text
+// format, to be mounted with server.WithMetricsHandler.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		m.registerer,
+		promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{}),
+	)
+}
+
+// register registers c, or returns the equivalent collector that is already
+// registered. Other registration errors are appended to errs.
+func register[T prometheus.Collector](reg prometheus.Registerer, errs *[]error, c T) T {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		*errs = append(*errs, fmt.Errorf("metrics: failed to register collector: %w", err))
+	}
+	return c
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// CacheObserver returns an observer for cache.WithObserver recording the
+// number and duration of cache operations by operation and outcome, from
+// which the hit ratio of GetOrSet can be derived.
+func (m *Metrics) CacheObserver() *CacheObserver {
+	return &CacheObserver{m: m}
+}
+
+// QueueObserver returns an observer for queue.WithObserver recording the
+// number and latency of publish, consume and delete calls.
+func (m *Metrics) QueueObserver() *QueueObserver {
+	return &QueueObserver{m: m}
+}
+
+// DBObserver returns an observer for dbutil.RegisterObserver recording the
+// number and duration of transactions run by ExecTx by outcome.
+func (m *Metrics) DBObserver() *DBObserver {
+	return &DBObserver{m: m}
+}
+
+// CacheObserver implements cache.Observer.
+type CacheObserver struct {
+	m *Metrics
+}
+
+// Start implements cache.Observer.
+func (o *CacheObserver) Start(ctx context.Context, op, _ string) (context.Context, func(outcome string, err error)) {
+	start := time.Now()
+	return ctx, func(outcome string, _ error) {
+		o.m.cacheOps.WithLabelValues(op, outcome).Inc()
+		o.m.cacheDuration.WithLabelValues(op, outcome).Observe(time.Since(start).Seconds())
+	}
+}
+
+// QueueObserver implements queue.Observer.
+type QueueObserver struct {
+	m *Metrics
+}
+
+// Start implements queue.Observer.
+func (o *QueueObserver) Start(ctx context.Context, op string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		outcome := outcomeOK
+		if err != nil {
+			outcome = outcomeError
+		}
+		o.m.queueOps.WithLabelValues(op, outcome).Inc()
+		o.m.queueDuration.WithLabelValues(op, outcome).Observe(time.Since(start).Seconds())
+	}
+}
+
+// DBObserver implements dbutil.Observer.
+type DBObserver struct {
+	m *Metrics
+}
+
+// Start implements dbutil.Observer.
+func (o *DBObserver) Start(ctx context.Context) (context.Context, func(outcome string, err error)) {
+	start := time.Now()
+	return ctx, func(outcome string, _ error) {
+		o.m.txTotal.WithLabelValues(outcome).Inc()
+		o.m.txDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+	}
+}
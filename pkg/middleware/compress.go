@@ -0,0 +1,362 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	encodingBrotli  = "br"
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// encodingPreference lists the supported encodings from most to least
+// preferred, used to break ties between equal q-values in Accept-Encoding.
+var encodingPreference = []string{encodingBrotli, encodingGzip, encodingDeflate}
+
+var defaultCompressibleTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/*",
+}
+
+// The deflate content coding is the zlib format (RFC 9110 §8.4.1.2), not a
+// raw DEFLATE stream.
+var (
+	gzipPool    = sync.Pool{New: func() any { w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression); return w }}
+	deflatePool = sync.Pool{New: func() any { w, _ := zlib.NewWriterLevel(io.Discard, zlib.DefaultCompression); return w }}
+	brotliPool  = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression) }}
+)
+
+type compressConfig struct {
+	minSize      int
+	contentTypes []string
+}
+
+// WithCompressMinSize sets the minimum response size, in bytes, required
+// before a response is compressed. Defaults to 1024.
+func WithCompressMinSize(n int) func(*compressConfig) {
+	return func(c *compressConfig) {
+		c.minSize = n
+	}
+}
+
+// WithCompressContentTypes replaces the list of content types eligible for
+// compression. Entries ending in "/*" match any subtype.
+func WithCompressContentTypes(types ...string) func(*compressConfig) {
+	return func(c *compressConfig) {
+		c.contentTypes = types
+	}
+}
+
+// Compress returns a middleware that compresses responses with brotli, gzip
+// or deflate, negotiated from the request's Accept-Encoding header.
+//
+// A response is compressed only when its Content-Type is in the allowlist and
+// its body reaches the minimum size. Responses that already carry a
+// Content-Encoding, Server-Sent Events streams, and responses flushed before
+// the minimum size is reached are passed through unchanged so streaming
+// handlers keep working.
+//
+// Example:
+//
+//	router.Use(middleware.Compress(
+//	    middleware.WithCompressMinSize(2048),
+//	))
+func Compress(opts ...func(*compressConfig)) func(http.Handler) http.Handler {
+	cfg := compressConfig{
+		minSize:      1024,
+		contentTypes: defaultCompressibleTypes,
+	}
+
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				config:         &cfg,
+				encoding:       encoding,
+				statusCode:     http.StatusOK,
+			}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks the supported encoding with the highest q-value in
+// the Accept-Encoding header. It returns an empty string when no supported
+// encoding is acceptable.
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	wildcard := -1.0
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range encodingPreference {
+		q, ok := weights[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	return best
+}
+
+// compressWriter buffers the beginning of the response until it can decide
+// whether compression is worthwhile, then either streams through an encoder
+// or writes the buffered bytes unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	config     *compressConfig
+	encoding   string
+	statusCode int
+
+	buf         []byte
+	decided     bool
+	wroteHeader bool
+	encoder     io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader || cw.decided {
+		return
+	}
+
+	// Informational responses are forwarded as-is and do not end the headers.
+	if code >= 100 && code < 200 {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	cw.wroteHeader = true
+	cw.statusCode = code
+
+	if !bodyAllowed(code) {
+		_ = cw.commit(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	if !cw.compressible() {
+		_ = cw.commit(false)
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.config.minSize {
+		if err := cw.commit(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Flush sends any buffered data to the client. Flushing before a decision is
+// made means the handler is streaming, so the response is sent uncompressed.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			cw.WriteHeader(http.StatusOK)
+		}
+		_ = cw.commit(false)
+	}
+
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close finishes the response once the handler returns, writing any bytes
+// still buffered below the size threshold and closing the encoder.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			// Nothing was written at all; let net/http send its default response.
+			return nil
+		}
+		if err := cw.commit(false); err != nil {
+			return err
+		}
+	}
+
+	if cw.encoder == nil {
+		return nil
+	}
+
+	err := cw.encoder.Close()
+	cw.releaseEncoder()
+	return err
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Hijack lets WebSocket upgrades pass through the middleware.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	cw.decided = true
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+// commit writes the response headers and any buffered body, switching to
+// compressed output when compress is true.
+func (cw *compressWriter) commit(compress bool) error {
+	cw.decided = true
+	h := cw.Header()
+
+	if compress {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		cw.encoder = cw.acquireEncoder()
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+
+	if len(cw.buf) == 0 {
+		return nil
+	}
+
+	buf := cw.buf
+	cw.buf = nil
+
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// compressible reports whether the response headers allow compression.
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "text/event-stream" {
+		return false
+	}
+
+	for _, allowed := range cw.config.contentTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if mediaType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cw *compressWriter) acquireEncoder() io.WriteCloser {
+	switch cw.encoding {
+	case encodingBrotli:
+		w := brotliPool.Get().(*brotli.Writer)
+		w.Reset(cw.ResponseWriter)
+		return w
+	case encodingGzip:
+		w := gzipPool.Get().(*gzip.Writer)
+		w.Reset(cw.ResponseWriter)
+		return w
+	case encodingDeflate:
+		w := deflatePool.Get().(*zlib.Writer)
+		w.Reset(cw.ResponseWriter)
+		return w
+	default:
+		panic(errors.New("unsupported encoding: " + cw.encoding))
+	}
+}
+
+func (cw *compressWriter) releaseEncoder() {
+	switch w := cw.encoder.(type) {
+	case *brotli.Writer:
+		brotliPool.Put(w)
+	case *gzip.Writer:
+		gzipPool.Put(w)
+	case *zlib.Writer:
+		deflatePool.Put(w)
+	}
+	cw.encoder = nil
+}
+
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified
+}
@@ -0,0 +1,52 @@
+// Package middleware provides reusable net/http middleware that builds on the
+// other gogem packages.
+//
+// Every middleware has the standard func(http.Handler) http.Handler shape, so
+// it works with chi, gorilla, the stdlib mux, or any custom router.
+//
+// # Idempotency
+//
+// Idempotency makes unsafe requests (POST, PUT, PATCH, DELETE) carrying an
+// Idempotency-Key header safe to retry. The first response for a key is
+// stored in the cache and replayed for repeated requests:
+//
+//	router.Use(middleware.Idempotency(cacheClient,
+//	    func(r *http.Request) string { return auth.UserID(r.Context()) },
+//	    middleware.WithIdempotencyTTL(24*time.Hour),
+//	))
+//
+// Keys are scoped by the user returned from the required user function;
+// requests without a user are not deduplicated. Responses larger than
+// WithIdempotencyMaxResponseSize are sent but not stored. A request
+// whose key is still being processed, or whose payload differs from the
+// original request, receives a 409 conflict fault. Replayed responses carry
+// the Idempotent-Replayed: true header.
+//
+// # Compression
+//
+// Compress negotiates brotli, gzip or deflate from Accept-Encoding and
+// compresses responses whose Content-Type is in the allowlist once they reach
+// the minimum size:
+//
+//	router.Use(middleware.Compress(
+//	    middleware.WithCompressMinSize(1024),
+//	    middleware.WithCompressContentTypes("application/json", "text/*"),
+//	))
+//
+// Vary: Accept-Encoding is always set and Content-Length is dropped for
+// compressed responses. Server-Sent Events and handlers that flush before the
+// threshold is reached are streamed uncompressed.
+//
+// # Timeouts
+//
+// Timeout sets a per-route deadline on the request context and responds with
+// a 503 fault (or 504 with WithTimeoutStatus) as soon as it is exceeded, if
+// the handler has not written a response yet. WithReadDeadline and WithWriteDeadline extend the
+// connection deadlines through http.ResponseController, so a slow route does
+// not force a higher ReadTimeout/WriteTimeout on the whole server:
+//
+//	router.With(middleware.Timeout(2*time.Minute,
+//	    middleware.WithTimeoutStatus(http.StatusGatewayTimeout),
+//	    middleware.WithWriteDeadline(2*time.Minute+5*time.Second),
+//	)).Get("/exports", exportHandler)
+package middleware
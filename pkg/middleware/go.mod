@@ -0,0 +1,16 @@
+module github.com/bernardinorafael/gogem/pkg/middleware
+
+go 1.24.1
+
+require (
+	github.com/andybalholm/brotli v1.2.6
+	github.com/bernardinorafael/gogem/cache v0.1.0
+	github.com/bernardinorafael/gogem/fault v0.1.0
+	github.com/bernardinorafael/gogem/httputil v0.1.0
+)
+
+replace (
+	github.com/bernardinorafael/gogem/cache => ../cache
+	github.com/bernardinorafael/gogem/fault => ../fault
+	github.com/bernardinorafael/gogem/httputil => ../httputil
+)
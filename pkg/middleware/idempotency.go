@@ -0,0 +1,280 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/cache"
+	"github.com/bernardinorafael/gogem/pkg/fault"
+	"github.com/bernardinorafael/gogem/pkg/httputil"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentBodyBytes    = 1_048_576 // 1MB
+)
+
+type idempotencyConfig struct {
+	prefix          string
+	ttl             time.Duration
+	lockTimeout     time.Duration
+	maxResponseSize int
+}
+
+// idempotencyRecord is the value stored in the cache for each key. While the
+// first request is being processed only the fingerprint is set; once it
+// completes the response is stored so it can be replayed.
+type idempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	StatusCode  int         `json:"status_code"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// WithIdempotencyPrefix sets the prefix used for cache keys. Defaults to "idempotency".
+func WithIdempotencyPrefix(prefix string) func(*idempotencyConfig) {
+	return func(c *idempotencyConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithIdempotencyTTL sets how long completed responses are kept for replay. Defaults to 24h.
+func WithIdempotencyTTL(d time.Duration) func(*idempotencyConfig) {
+	return func(c *idempotencyConfig) {
+		c.ttl = d
+	}
+}
+
+// WithIdempotencyLockTimeout sets how long a key stays locked while its first
+// request is in flight. It bounds how long a crashed request blocks retries.
+// Defaults to 1m.
+func WithIdempotencyLockTimeout(d time.Duration) func(*idempotencyConfig) {
+	return func(c *idempotencyConfig) {
+		c.lockTimeout = d
+	}
+}
+
+// WithIdempotencyMaxResponseSize sets the size in bytes above which a
+// response body is not stored. Such responses are sent normally but not
+// replayed, so a retry runs the handler again. Defaults to 1MB.
+func WithIdempotencyMaxResponseSize(n int) func(*idempotencyConfig) {
+	return func(c *idempotencyConfig) {
+		c.maxResponseSize = n
+	}
+}
+
+// Idempotency returns a middleware that makes unsafe requests carrying an
+// Idempotency-Key header safe to retry. The first response for a key (status,
+// headers and body) is stored in the cache and replayed for repeated
+// requests with the same key.
+//
+// Keys are scoped by the user returned by user, so two users sending the
+// same key never share a response. It typically reads the user ID stored in
+// the context by the authentication middleware. Requests for which it
+// returns an empty string pass through untouched, since their keys could not
+// be told apart between users. Idempotency panics if user is nil.
+//
+// Requests without the header, and GET, HEAD and OPTIONS requests, pass
+// through untouched. A conflict fault is returned when a request with the
+// same key is still in flight or when its payload differs from the original.
+// Responses with a 5xx status are not stored, so the client can retry them.
+//
+// Example:
+//
+//	router.Use(middleware.Idempotency(cacheClient, func(r *http.Request) string {
+//	    return auth.UserID(r.Context())
+//	}))
+func Idempotency(client *cache.Client, user func(*http.Request) string, opts ...func(*idempotencyConfig)) func(http.Handler) http.Handler {
+	if user == nil {
+		panic("middleware: Idempotency requires a user function")
+	}
+
+	cfg := idempotencyConfig{
+		prefix:          "idempotency",
+		ttl:             24 * time.Hour,
+		lockTimeout:     time.Minute,
+		maxResponseSize: 1_048_576, // 1MB
+	}
+
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idemKey := r.Header.Get(idempotencyKeyHeader)
+			if idemKey == "" || isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID := user(r)
+			if userID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(idemKey) > maxIdempotencyKeyLength {
+				httputil.WriteError(w, fault.NewBadRequest("idempotency key is too long"))
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodyBytes+1))
+			if err != nil {
+				httputil.WriteError(w, fault.NewBadRequest("failed to read request body"))
+				return
+			}
+			if len(body) > maxIdempotentBodyBytes {
+				httputil.WriteError(w, fault.NewBadRequest("request body is too large"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			key := cfg.prefix + ":" + userID + ":" + idemKey
+			fingerprint := fingerprintRequest(r, body)
+
+			acquired, err := cache.SetNX(ctx, cache.SetParams{
+				Client: client,
+				Key:    key,
+				TTL:    cfg.lockTimeout,
+			}, idempotencyRecord{Fingerprint: fingerprint})
+			if err != nil {
+				httputil.WriteError(w, fault.NewInternalServerError("failed to process idempotency key", fault.WithErr(err)))
+				return
+			}
+
+			if !acquired {
+				record, err := cache.Get[idempotencyRecord](ctx, client, key)
+				if err != nil {
+					if fault.GetTag(err) == fault.NotFound {
+						// The lock expired between SetNX and Get; ask the client to retry.
+						httputil.WriteError(w, fault.NewConflict("request with this idempotency key is in progress"))
+						return
+					}
+					httputil.WriteError(w, fault.NewInternalServerError("failed to process idempotency key", fault.WithErr(err)))
+					return
+				}
+
+				switch {
+				case record.Fingerprint != fingerprint:
+					httputil.WriteError(w, fault.NewConflict("idempotency key was already used with a different payload"))
+				case !record.Completed:
+					httputil.WriteError(w, fault.NewConflict("request with this idempotency key is in progress"))
+				default:
+					replayResponse(w, record)
+				}
+				return
+			}
+
+			// Use a context detached from the request so the record is stored
+			// even if the client disconnected while the handler was running.
+			storeCtx := context.WithoutCancel(ctx)
+
+			// Release the key unless the response was stored, including when
+			// the handler panics, so retries are not blocked until the lock
+			// times out.
+			stored := false
+			defer func() {
+				if !stored {
+					_ = cache.Delete(storeCtx, client, key)
+				}
+			}()
+
+			rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK, maxBody: cfg.maxResponseSize}
+			next.ServeHTTP(rec, r)
+
+			if rec.statusCode >= http.StatusInternalServerError || rec.truncated {
+				return
+			}
+
+			err = cache.Set(storeCtx, cache.SetParams{
+				Client: client,
+				Key:    key,
+				TTL:    cfg.ttl,
+			}, idempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				StatusCode:  rec.statusCode,
+				Header:      w.Header().Clone(),
+				Body:        rec.body.Bytes(),
+			})
+			stored = err == nil
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// fingerprintRequest hashes the parts of the request that must match for a
+// retry to be considered the same request.
+func fingerprintRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replayResponse(w http.ResponseWriter, record idempotencyRecord) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	_, _ = w.Write(record.Body)
+}
+
+// responseRecorder writes through to the underlying ResponseWriter while
+// keeping a copy of the status code and of up to maxBody bytes of the body.
+// truncated is set once the body exceeds maxBody and the copy is dropped.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	maxBody     int
+	truncated   bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.statusCode = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.truncated {
+		if r.body.Len()+len(b) > r.maxBody {
+			r.truncated = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
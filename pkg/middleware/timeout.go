@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+	"github.com/bernardinorafael/gogem/pkg/httputil"
+)
+
+type timeoutConfig struct {
+	statusCode    int
+	message       string
+	readDeadline  time.Duration
+	writeDeadline time.Duration
+}
+
+// WithTimeoutStatus sets the status of the fault returned when the deadline
+// is exceeded: http.StatusServiceUnavailable (default, tagged
+// ServiceUnavailable) or http.StatusGatewayTimeout (tagged GatewayTimeout).
+// Any other code falls back to the default.
+func WithTimeoutStatus(code int) func(*timeoutConfig) {
+	return func(c *timeoutConfig) {
+		c.statusCode = code
+	}
+}
+
+// WithTimeoutMessage sets the message of the fault returned when the deadline
+// is exceeded.
+func WithTimeoutMessage(msg string) func(*timeoutConfig) {
+	return func(c *timeoutConfig) {
+		c.message = msg
+	}
+}
+
+// WithReadDeadline extends the connection read deadline to d from the start
+// of the request, overriding the server's ReadTimeout for this route. Use it
+// for routes receiving large uploads.
+func WithReadDeadline(d time.Duration) func(*timeoutConfig) {
+	return func(c *timeoutConfig) {
+		c.readDeadline = d
+	}
+}
+
+// WithWriteDeadline extends the connection write deadline to d from the start
+// of the request, overriding the server's WriteTimeout for this route. It
+// should be slightly longer than the timeout so the timeout fault can still
+// be written.
+func WithWriteDeadline(d time.Duration) func(*timeoutConfig) {
+	return func(c *timeoutConfig) {
+		c.writeDeadline = d
+	}
+}
+
+// Timeout returns a middleware that sets a deadline of d on the request
+// context. If the deadline is exceeded before the handler writes a response,
+// the client receives a 503 fault (or 504, see WithTimeoutStatus) right away,
+// even if the handler ignores the context, and anything the handler writes
+// afterwards is discarded. Handlers should pass the request context to the
+// database, cache and HTTP calls they make so they stop working once the
+// deadline passes.
+//
+// The handler runs in its own goroutine. Its output is not buffered, so
+// streaming responses keep working: once it has started writing, the
+// middleware waits for it to return. Panics are propagated to the request
+// goroutine.
+//
+// Example:
+//
+//	router.With(middleware.Timeout(2*time.Minute,
+//	    middleware.WithWriteDeadline(2*time.Minute+5*time.Second),
+//	)).Get("/exports", exportHandler)
+func Timeout(d time.Duration, opts ...func(*timeoutConfig)) func(http.Handler) http.Handler {
+	cfg := timeoutConfig{
+		statusCode: http.StatusServiceUnavailable,
+		message:    "request timed out",
+	}
+
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rc := http.NewResponseController(w)
+
+			// Extending deadlines is best effort: not every ResponseWriter
+			// supports it.
+			if cfg.readDeadline > 0 {
+				_ = rc.SetReadDeadline(start.Add(cfg.readDeadline))
+			}
+			if cfg.writeDeadline > 0 {
+				_ = rc.SetWriteDeadline(start.Add(cfg.writeDeadline))
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{
+				ResponseWriter: w,
+				header:         make(http.Header),
+				ctx:            ctx,
+				timeoutFault:   cfg.fault,
+			}
+
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				if !tw.wroteHeader {
+					if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+						tw.writeTimeout()
+					} else {
+						// The handler wrote nothing: send its headers.
+						tw.writeHeader(http.StatusOK)
+					}
+				}
+			case p := <-panicked:
+				panic(p)
+			case <-ctx.Done():
+				tw.mu.Lock()
+				if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					tw.writeTimeout()
+					tw.mu.Unlock()
+					return
+				}
+				tw.mu.Unlock()
+
+				// The response has started, or the client went away: the
+				// handler still owns the ResponseWriter until it returns.
+				select {
+				case <-done:
+				case p := <-panicked:
+					panic(p)
+				}
+			}
+		})
+	}
+}
+
+func (c timeoutConfig) fault() *fault.Fault {
+	if c.statusCode == http.StatusGatewayTimeout {
+		return fault.NewGatewayTimeout(c.message)
+	}
+	return fault.NewServiceUnavailable(c.message)
+}
+
+// timeoutWriter replaces the handler's response with a timeout fault when the
+// deadline passes before the handler starts writing. The handler's headers
+// are kept apart until it writes, since it may still set them after the
+// timeout fault was sent.
+type timeoutWriter struct {
+	http.ResponseWriter
+	header       http.Header
+	ctx          context.Context
+	timeoutFault func() *fault.Fault
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.wroteHeader {
+		return
+	}
+	if errors.Is(tw.ctx.Err(), context.DeadlineExceeded) {
+		tw.writeTimeout()
+		return
+	}
+
+	tw.writeHeader(code)
+}
+
+// writeHeader sends the handler's headers and status. The caller must hold
+// tw.mu.
+func (tw *timeoutWriter) writeHeader(code int) {
+	tw.wroteHeader = true
+
+	dst := tw.ResponseWriter.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	wrote := tw.wroteHeader
+	tw.mu.Unlock()
+
+	if !wrote {
+		tw.WriteHeader(http.StatusOK)
+	}
+
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.timedOut {
+		_ = http.NewResponseController(tw.ResponseWriter).Flush()
+	}
+}
+
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+
+	conn, rw, err := http.NewResponseController(tw.ResponseWriter).Hijack()
+	if err == nil {
+		// The handler owns the connection: never write a response on it.
+		tw.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// writeTimeout writes the timeout fault. The caller must hold tw.mu.
+func (tw *timeoutWriter) writeTimeout() {
+	tw.wroteHeader = true
+	tw.timedOut = true
+
+	httputil.WriteError(tw.ResponseWriter, tw.timeoutFault())
+}
@@ -0,0 +1,58 @@
+// Package openapi generates an OpenAPI 3.1 description of an HTTP API from
+// the Go types its handlers read and write.
+//
+// Routes are documented where they are registered. Request and response
+// schemas are derived by reflection following encoding/json rules, including
+// pagination.Paginated and apiutil.Expandable, and the fault tags a route can
+// respond with are documented with the fault.Fault schema:
+//
+//	doc := openapi.New("Orders API", "1.0.0")
+//	mux := http.NewServeMux()
+//
+//	openapi.HandleValidated[CreateUserDTO](doc, mux, "POST /users", createUser,
+//	    openapi.Summary("Create a user"),
+//	    openapi.Tags("users"),
+//	    openapi.Response[User](http.StatusCreated),
+//	    openapi.Faults(fault.Conflict),
+//	)
+//
+//	openapi.HandleJSON(doc, mux, "PUT /users/{id}", http.StatusOK, updateUser,
+//	    openapi.Faults(fault.NotFound),
+//	)
+//
+//	doc.Handle(mux, "GET /users", http.HandlerFunc(listUsers),
+//	    openapi.Query[int]("page", "page number, starting at 1"),
+//	    openapi.Response[pagination.Paginated[User]](http.StatusOK),
+//	)
+//
+//	doc.Handle(mux, "DELETE /users/{id}", http.HandlerFunc(deleteUser),
+//	    openapi.EmptyResponse(http.StatusNoContent),
+//	    openapi.Faults(fault.NotFound),
+//	)
+//
+// HandleValidated and HandleJSON build the handler with httputil and take
+// the request body, and for HandleJSON the response body, from its type
+// parameters, so the documented types are the ones the handler uses. Routes
+// registered with Handle declare them with Request and Response.
+//
+// Path parameters are read from the pattern. Request bodies implementing
+// httputil.Validator also document the 400 and 422 faults returned by
+// WithValidation. Named struct types become components; generic types are
+// named after their type arguments, e.g. PaginatedUser. Field descriptions
+// and examples are read from the description and example struct tags.
+//
+// For routers without a Handle(pattern, handler) method, such as chi's
+// method helpers, document the route with Add and register it as usual:
+//
+//	doc.Add("GET /orders/{id}", openapi.Response[Order](http.StatusOK))
+//	router.Get("/orders/{id}", getOrder)
+//
+// The document is generated on demand with JSON and YAML, or served with
+// Handler:
+//
+//	srv := server.New(
+//	    server.WithHandler(mux),
+//	    server.WithOpenAPIHandler(doc.Handler()),
+//	)
+//	// GET /openapi.json, GET /openapi.yaml
+package openapi
@@ -0,0 +1,14 @@
+module github.com/bernardinorafael/gogem/pkg/openapi
+
+go 1.24.1
+
+require (
+	github.com/bernardinorafael/gogem/fault v0.1.0
+	github.com/bernardinorafael/gogem/httputil v0.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace (
+	github.com/bernardinorafael/gogem/fault => ../fault
+	github.com/bernardinorafael/gogem/httputil => ../httputil
+)
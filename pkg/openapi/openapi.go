@@ -0,0 +1,324 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+	"github.com/bernardinorafael/gogem/pkg/httputil"
+	"gopkg.in/yaml.v3"
+)
+
+const openAPIVersion = "3.1.0"
+
+// pathParamRe matches path parameters such as {id}, {id:[0-9]+} (chi) and
+// {path...} (ServeMux).
+var pathParamRe = regexp.MustCompile(`\{([^}:.]+)(?:\.\.\.)?(?::[^}]*)?\}`)
+
+// faultStatus maps the tags defined by the fault package to the HTTP status
+// their constructors use.
+var faultStatus = map[fault.Tag]int{
+	fault.BadRequest:          http.StatusBadRequest,
+	fault.NotFound:            http.StatusNotFound,
+	fault.InternalServerError: http.StatusInternalServerError,
+	fault.Unauthorized:        http.StatusUnauthorized,
+	fault.Forbidden:           http.StatusForbidden,
+	fault.Conflict:            http.StatusConflict,
+	fault.TooManyRequests:     http.StatusTooManyRequests,
+	fault.ValidationError:     http.StatusUnprocessableEntity,
+	fault.UnprocessableEntity: http.StatusUnprocessableEntity,
+	fault.ServiceUnavailable:  http.StatusServiceUnavailable,
+	fault.GatewayTimeout:      http.StatusGatewayTimeout,
+}
+
+// Router is implemented by routers accepting "METHOD /path" patterns, such
+// as http.ServeMux and chi.Router.
+type Router interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Document collects the routes of an API and generates its OpenAPI 3.1
+// description.
+type Document struct {
+	info        info
+	servers     []server
+	faultStatus map[fault.Tag]int
+
+	mu     sync.Mutex
+	routes []*operation
+}
+
+// WithDescription sets the description of the API.
+func WithDescription(desc string) func(*Document) {
+	return func(d *Document) {
+		d.info.Description = desc
+	}
+}
+
+// WithServer adds a server the API is available at.
+func WithServer(url, description string) func(*Document) {
+	return func(d *Document) {
+		d.servers = append(d.servers, server{URL: url, Description: description})
+	}
+}
+
+// WithFaultStatus sets the HTTP status documented for faults with tag, for
+// tags not defined by the fault package or faults created with a custom
+// status. Faults with unknown tags are documented as 500.
+func WithFaultStatus(tag fault.Tag, status int) func(*Document) {
+	return func(d *Document) {
+		d.faultStatus[tag] = status
+	}
+}
+
+// New creates an empty document for the API with the given title and
+// version.
+//
+// Example:
+//
+//	doc := openapi.New("Orders API", "1.0.0",
+//	    openapi.WithServer("https://api.example.com", "production"),
+//	)
+func New(title, version string, opts ...func(*Document)) *Document {
+	d := Document{
+		info:        info{Title: title, Version: version},
+		faultStatus: make(map[fault.Tag]int, len(faultStatus)),
+	}
+
+	for tag, status := range faultStatus {
+		d.faultStatus[tag] = status
+	}
+
+	for _, fn := range opts {
+		fn(&d)
+	}
+
+	return &d
+}
+
+// Add documents the route matching pattern, which has the "METHOD /path"
+// form, without registering a handler. Use it with routers that do not
+// implement Router.
+func (d *Document) Add(pattern string, opts ...func(*operation)) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		path, method = method, http.MethodGet
+	}
+
+	op := operation{
+		method:    strings.ToLower(method),
+		path:      pathParamRe.ReplaceAllString(strings.TrimSuffix(path, "{$}"), "{$1}"),
+		responses: make(map[int]reflect.Type),
+	}
+
+	for _, fn := range opts {
+		fn(&op)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.routes = append(d.routes, &op)
+}
+
+// Handle registers handler on router and documents the route. For handlers
+// built by httputil, prefer HandleValidated and HandleJSON, which document
+// the request and response types of the handler.
+//
+// Example:
+//
+//	doc.Handle(mux, "GET /users", http.HandlerFunc(listUsers),
+//	    openapi.Summary("List users"),
+//	    openapi.Response[pagination.Paginated[User]](http.StatusOK),
+//	)
+func (d *Document) Handle(router Router, pattern string, handler http.Handler, opts ...func(*operation)) {
+	d.Add(pattern, opts...)
+	router.Handle(pattern, handler)
+}
+
+// HandleFunc registers handler on router and documents the route.
+func (d *Document) HandleFunc(router Router, pattern string, handler http.HandlerFunc, opts ...func(*operation)) {
+	d.Handle(router, pattern, handler, opts...)
+}
+
+// HandleValidated registers httputil.WithValidation[T](next) on router and
+// documents T as the request body, so the documented body is the DTO the
+// handler actually decodes.
+//
+// Example:
+//
+//	openapi.HandleValidated[CreateUserDTO](doc, mux, "POST /users", createUser,
+//	    openapi.Response[User](http.StatusCreated),
+//	)
+func HandleValidated[T httputil.Validator](d *Document, router Router, pattern string, next http.HandlerFunc, opts ...func(*operation)) {
+	opts = append([]func(*operation){Request[T]()}, opts...)
+	d.Handle(router, pattern, httputil.WithValidation[T](next), opts...)
+}
+
+// HandleJSON registers on router the handler httputil.Handle builds from fn,
+// responding with status on success, and documents Req as the request body
+// and Res as the response body for status. Both are taken from fn, so they
+// cannot drift from the handler.
+//
+// Example:
+//
+//	openapi.HandleJSON(doc, mux, "POST /users", http.StatusCreated,
+//	    func(ctx context.Context, dto CreateUserDTO) (User, error) {
+//	        return users.Create(ctx, dto)
+//	    },
+//	    openapi.Faults(fault.Conflict),
+//	)
+func HandleJSON[Req, Res any](d *Document, router Router, pattern string, status int, fn func(context.Context, Req) (Res, error), opts ...func(*operation)) {
+	opts = append([]func(*operation){Request[Req](), Response[Res](status)}, opts...)
+	d.Handle(router, pattern, httputil.Handle(fn, httputil.WithStatus(status)), opts...)
+}
+
+// JSON returns the OpenAPI document encoded as JSON.
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d.build(), "", "  ")
+}
+
+// YAML returns the OpenAPI document encoded as YAML.
+func (d *Document) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(d.build()); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Handler serves the document as YAML when the request path ends in .yaml
+// or .yml, and as JSON otherwise. Mount it with server.WithOpenAPIHandler.
+func (d *Document) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := "application/json"
+		encode := d.JSON
+		if strings.HasSuffix(r.URL.Path, ".yaml") || strings.HasSuffix(r.URL.Path, ".yml") {
+			contentType = "application/yaml"
+			encode = d.YAML
+		}
+
+		b, err := encode()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(b)
+	})
+}
+
+func (d *Document) build() spec {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	g := newSchemaGenerator()
+	faultSchema := g.schemaOf(reflect.TypeFor[fault.Fault]())
+
+	paths := make(map[string]pathItem)
+	for _, op := range d.routes {
+		item, ok := paths[op.path]
+		if !ok {
+			item = make(pathItem)
+			paths[op.path] = item
+		}
+		item[op.method] = d.operationSpec(g, op, faultSchema)
+	}
+
+	return spec{
+		OpenAPI:    openAPIVersion,
+		Info:       d.info,
+		Servers:    d.servers,
+		Paths:      paths,
+		Components: components{Schemas: g.schemas},
+	}
+}
+
+func (d *Document) operationSpec(g *schemaGenerator, op *operation, faultSchema *schema) *operationSpec {
+	s := operationSpec{
+		OperationID: op.id,
+		Summary:     op.summary,
+		Description: op.description,
+		Tags:        op.tags,
+		Deprecated:  op.deprecated,
+		Responses:   make(map[string]response),
+	}
+
+	for _, m := range pathParamRe.FindAllStringSubmatch(op.path, -1) {
+		s.Parameters = append(s.Parameters, parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   &schema{Type: "string"},
+		})
+	}
+	for _, q := range op.query {
+		s.Parameters = append(s.Parameters, parameter{
+			Name:        q.name,
+			In:          "query",
+			Description: q.description,
+			Schema:      g.schemaOf(q.typ),
+		})
+	}
+
+	faults := op.faults
+	if op.request != nil {
+		s.RequestBody = &requestBody{
+			Required: true,
+			Content:  map[string]mediaType{"application/json": {Schema: g.schemaOf(op.request)}},
+		}
+
+		// ReadRequestBody rejects malformed bodies and WithValidation
+		// rejects invalid ones.
+		faults = append(faults, fault.BadRequest)
+		if implements(op.request, reflect.TypeFor[interface{ Validate() error }]()) {
+			faults = append(faults, fault.ValidationError)
+		}
+	}
+
+	for status, typ := range op.responses {
+		r := response{Description: http.StatusText(status)}
+		if typ != nil {
+			r.Content = map[string]mediaType{"application/json": {Schema: g.schemaOf(typ)}}
+		}
+		s.Responses[strconv.Itoa(status)] = r
+	}
+	if len(s.Responses) == 0 {
+		s.Responses["200"] = response{Description: http.StatusText(http.StatusOK)}
+	}
+
+	byStatus := make(map[int][]string)
+	for _, tag := range faults {
+		status, ok := d.faultStatus[tag]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		if !slices.Contains(byStatus[status], string(tag)) {
+			byStatus[status] = append(byStatus[status], string(tag))
+		}
+	}
+	for status, tags := range byStatus {
+		s.Responses[strconv.Itoa(status)] = response{
+			Description: http.StatusText(status) + " (" + strings.Join(tags, ", ") + ")",
+			Content:     map[string]mediaType{"application/json": {Schema: faultSchema}},
+		}
+	}
+
+	return &s
+}
@@ -0,0 +1,120 @@
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bernardinorafael/gogem/pkg/openapi"
+)
+
+type CreateUserDTO struct {
+	Name string `json:"name"`
+}
+
+func (d CreateUserDTO) Validate() error {
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type document struct {
+	Paths map[string]map[string]struct {
+		RequestBody struct {
+			Content map[string]struct {
+				Schema struct {
+					Ref string `json:"$ref"`
+				} `json:"schema"`
+			} `json:"content"`
+		} `json:"requestBody"`
+		Responses map[string]struct {
+			Content map[string]struct {
+				Schema struct {
+					Ref string `json:"$ref"`
+				} `json:"schema"`
+			} `json:"content"`
+		} `json:"responses"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]json.RawMessage `json:"schemas"`
+	} `json:"components"`
+}
+
+func TestTypedHandlersDocumentTheirTypes(t *testing.T) {
+	doc := openapi.New("Users", "1.0.0")
+	mux := http.NewServeMux()
+
+	openapi.HandleValidated[CreateUserDTO](doc, mux, "POST /users",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		},
+		openapi.Response[User](http.StatusCreated),
+	)
+	openapi.HandleJSON(doc, mux, "PUT /users/{id}", http.StatusOK,
+		func(ctx context.Context, dto CreateUserDTO) (User, error) {
+			return User{ID: "1", Name: dto.Name}, nil
+		},
+	)
+
+	data, err := doc.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	var got document
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	tests := []struct {
+		path, method, status string
+	}{
+		{path: "/users", method: "post", status: "201"},
+		{path: "/users/{id}", method: "put", status: "200"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			op, ok := got.Paths[tt.path][tt.method]
+			if !ok {
+				t.Fatalf("operation %s %s not documented", tt.method, tt.path)
+			}
+
+			if ref := op.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/CreateUserDTO" {
+				t.Errorf("request body $ref = %q, want CreateUserDTO", ref)
+			}
+			if ref := op.Responses[tt.status].Content["application/json"].Schema.Ref; ref != "#/components/schemas/User" {
+				t.Errorf("response %s $ref = %q, want User", tt.status, ref)
+			}
+			// CreateUserDTO implements httputil.Validator.
+			for _, status := range []string{"400", "422"} {
+				if _, ok := op.Responses[status]; !ok {
+					t.Errorf("response %s not documented", status)
+				}
+			}
+		})
+	}
+
+	for _, name := range []string{"CreateUserDTO", "User"} {
+		if _, ok := got.Components.Schemas[name]; !ok {
+			t.Errorf("component %s not generated", name)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/users/1", strings.NewReader(`{"name":"ana"}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ana"`) {
+		t.Errorf("PUT /users/1 = %d %s, want 200 with the user", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,109 @@
+package openapi
+
+import (
+	"reflect"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+// operation describes a documented route.
+type operation struct {
+	method      string
+	path        string
+	id          string
+	summary     string
+	description string
+	tags        []string
+	deprecated  bool
+	request     reflect.Type
+	query       []queryParam
+	responses   map[int]reflect.Type
+	faults      []fault.Tag
+}
+
+type queryParam struct {
+	name        string
+	description string
+	typ         reflect.Type
+}
+
+// Request documents T as the JSON request body. HandleValidated and
+// HandleJSON set it from the handler; use it for routes registered
+// otherwise. When T implements httputil.Validator, the 422 validation fault
+// is documented as well as the 400 fault for malformed bodies.
+func Request[T any]() func(*operation) {
+	return func(o *operation) {
+		o.request = reflect.TypeFor[T]()
+	}
+}
+
+// Response documents T as the JSON response body for status. T can be any
+// type serialized by encoding/json, including pagination.Paginated and types
+// with apiutil.Expandable fields.
+func Response[T any](status int) func(*operation) {
+	return func(o *operation) {
+		o.responses[status] = reflect.TypeFor[T]()
+	}
+}
+
+// EmptyResponse documents a response without a body, e.g. 204 No Content.
+func EmptyResponse(status int) func(*operation) {
+	return func(o *operation) {
+		o.responses[status] = nil
+	}
+}
+
+// Faults documents the fault tags the route can respond with. Tags sharing
+// an HTTP status are documented as a single response.
+func Faults(tags ...fault.Tag) func(*operation) {
+	return func(o *operation) {
+		o.faults = append(o.faults, tags...)
+	}
+}
+
+// Query documents an optional query parameter of type T.
+func Query[T any](name, description string) func(*operation) {
+	return func(o *operation) {
+		o.query = append(o.query, queryParam{
+			name:        name,
+			description: description,
+			typ:         reflect.TypeFor[T](),
+		})
+	}
+}
+
+// OperationID sets the unique identifier of the operation, used by code
+// generators to name methods.
+func OperationID(id string) func(*operation) {
+	return func(o *operation) {
+		o.id = id
+	}
+}
+
+// Summary sets a short summary of the operation.
+func Summary(summary string) func(*operation) {
+	return func(o *operation) {
+		o.summary = summary
+	}
+}
+
+// Description sets a detailed description of the operation.
+func Description(desc string) func(*operation) {
+	return func(o *operation) {
+		o.description = desc
+	}
+}
+
+// Tags groups the operation under the given tags in documentation tools.
+func Tags(tags ...string) func(*operation) {
+	return func(o *operation) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// Deprecated marks the operation as deprecated.
+func Deprecated() func(*operation) {
+	return func(o *operation) {
+		o.deprecated = true
+	}
+}
@@ -0,0 +1,242 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// expandablePkgPath identifies apiutil.Expandable, which marshals either as
+// the resource ID or as the full resource.
+const expandablePkgPath = "github.com/bernardinorafael/gogem/pkg/apiutil"
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+	// qualifierRe matches the package path qualifying a type argument, e.g.
+	// "github.com/acme/api/dto." in "Paginated[github.com/acme/api/dto.User]".
+	qualifierRe = regexp.MustCompile(`[\w\-./]*\.`)
+)
+
+// schemaGenerator derives JSON Schemas from Go types, the way encoding/json
+// serializes them. Named struct types become components referenced by $ref.
+type schemaGenerator struct {
+	schemas map[string]*schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &schema{}
+	case t.PkgPath() == expandablePkgPath && strings.HasPrefix(t.Name(), "Expandable["):
+		return g.expandableSchema(t)
+	case implements(t, marshalerType):
+		// The JSON shape of a custom marshaler cannot be derived from its type.
+		return &schema{}
+	case implements(t, textMarshalerType):
+		return &schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.component(t)
+	default:
+		return &schema{}
+	}
+}
+
+// component registers t under components/schemas and returns a reference
+// to it.
+func (g *schemaGenerator) component(t reflect.Type) *schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = componentName(t)
+		for i := 2; g.schemas[name] != nil; i++ {
+			name = componentName(t) + strconv.Itoa(i)
+		}
+
+		// Reserve the name before generating the schema so recursive types
+		// refer to themselves instead of recursing forever.
+		g.names[t] = name
+		g.schemas[name] = &schema{}
+		*g.schemas[name] = *g.structSchema(t)
+	}
+
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		// Embedded structs without a JSON name are flattened into the parent.
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded := g.structSchema(ft)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := g.schemaOf(f.Type)
+		if hasOption(opts, "string") {
+			fs = &schema{Type: "string"}
+		}
+		if desc := f.Tag.Get("description"); desc != "" {
+			fs.Description = desc
+		}
+		if example, ok := f.Tag.Lookup("example"); ok {
+			fs.Example = parseExample(ft.Kind(), example)
+		}
+
+		// Nil pointers are serialized as null.
+		if typ, ok := fs.Type.(string); ok && f.Type.Kind() == reflect.Pointer {
+			fs.Type = []string{typ, "null"}
+		}
+
+		s.Properties[name] = fs
+		if f.Type.Kind() != reflect.Pointer && !hasOption(opts, "omitempty") && !hasOption(opts, "omitzero") {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s
+}
+
+// expandableSchema describes apiutil.Expandable[T], which marshals as the
+// resource ID when collapsed and as T when expanded.
+func (g *schemaGenerator) expandableSchema(t reflect.Type) *schema {
+	data, ok := t.FieldByName("data")
+	if !ok {
+		return &schema{}
+	}
+
+	return &schema{OneOf: []*schema{
+		{Type: "string", Description: "ID of the resource when not expanded"},
+		g.schemaOf(data.Type),
+	}}
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func hasOption(opts, option string) bool {
+	for opt := range strings.SplitSeq(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExample converts the value of an example struct tag to the JSON type
+// of the field, falling back to the raw string.
+func parseExample(kind reflect.Kind, value string) any {
+	switch kind {
+	case reflect.Bool:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case reflect.Float32, reflect.Float64:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// componentName returns the schema name of a named type. Type arguments of
+// generic types are appended without their package path, so
+// Paginated[github.com/acme/api/dto.User] becomes PaginatedUser.
+func componentName(t reflect.Type) string {
+	base, args, ok := strings.Cut(t.Name(), "[")
+	if !ok {
+		return base
+	}
+
+	args = qualifierRe.ReplaceAllString(args, "")
+
+	var b strings.Builder
+	b.WriteString(base)
+	for _, word := range strings.FieldsFunc(args, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package openapi
+
+// The types below model the subset of the OpenAPI 3.1 document that is
+// generated from registered routes.
+
+type spec struct {
+	OpenAPI    string              `json:"openapi" yaml:"openapi"`
+	Info       info                `json:"info" yaml:"info"`
+	Servers    []server            `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths      map[string]pathItem `json:"paths" yaml:"paths"`
+	Components components          `json:"components" yaml:"components"`
+}
+
+type info struct {
+	Title       string `json:"title" yaml:"title"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type server struct {
+	URL         string `json:"url" yaml:"url"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type pathItem map[string]*operationSpec
+
+type operationSpec struct {
+	OperationID string              `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Deprecated  bool                `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Parameters  []parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *requestBody        `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]response `json:"responses" yaml:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name" yaml:"name"`
+	In          string  `json:"in" yaml:"in"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required" yaml:"required"`
+	Schema      *schema `json:"schema" yaml:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required" yaml:"required"`
+	Content  map[string]mediaType `json:"content" yaml:"content"`
+}
+
+type response struct {
+	Description string               `json:"description" yaml:"description"`
+	Content     map[string]mediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema" yaml:"schema"`
+}
+
+type components struct {
+	Schemas map[string]*schema `json:"schemas" yaml:"schemas"`
+}
+
+// schema is a JSON Schema as used by OpenAPI 3.1.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Example              any                `json:"example,omitempty" yaml:"example,omitempty"`
+	Items                *schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	OneOf                []*schema          `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+}
@@ -24,6 +24,25 @@
 //	    client.DeleteMessage(ctx, msg.ReceiptHandle)
 //	}
 //
+// Checking that the queue is reachable (e.g. as a server health check):
+//
+//	err := client.Ping(ctx)
+//
+// Observers are notified around Publish, Consume and DeleteMessage, e.g. to
+// record metrics:
+//
+//	client, err := queue.NewClient(ctx, cfg, queue.WithObserver(m.QueueObserver()))
+//
+// A propagator carries request-scoped values such as the trace context from
+// the publisher to the consumer in message attributes:
+//
+//	client, err := queue.NewClient(ctx, cfg, queue.WithPropagator(t.QueuePropagator()))
+//
+//	for _, msg := range messages {
+//	    ctx := client.MessageContext(ctx, msg)
+//	    processMessage(ctx, msg)
+//	}
+//
 // The client uses AWS default credential chain resolution (environment variables,
 // shared credentials file, IAM roles, etc.).
 package queue
@@ -0,0 +1,42 @@
+package queue
+
+import "context"
+
+// Operation names reported to observers.
+const (
+	OpPublish = "publish"
+	OpConsume = "consume"
+	OpDelete  = "delete"
+)
+
+// Observer is notified around queue operations, e.g. to record metrics or
+// traces. Start is called before the operation runs and returns the context
+// the operation runs with, along with a function called once it finishes.
+type Observer interface {
+	Start(ctx context.Context, op string) (context.Context, func(err error))
+}
+
+// WithObserver registers an observer on the client. Observers are started in
+// registration order and finished in reverse order.
+func WithObserver(o Observer) func(*Client) {
+	return func(c *Client) {
+		c.observers = append(c.observers, o)
+	}
+}
+
+func (c *Client) observe(ctx context.Context, op string) (context.Context, func(err error)) {
+	if len(c.observers) == 0 {
+		return ctx, func(error) {}
+	}
+
+	finishers := make([]func(error), len(c.observers))
+	for i, o := range c.observers {
+		ctx, finishers[i] = o.Start(ctx, op)
+	}
+
+	return ctx, func(err error) {
+		for i := len(finishers) - 1; i >= 0; i-- {
+			finishers[i](err)
+		}
+	}
+}
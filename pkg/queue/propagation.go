@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Propagator carries request-scoped values, such as trace context, from a
+// publisher to the consumer through message attributes.
+type Propagator interface {
+	// Inject writes the values carried by ctx into attrs.
+	Inject(ctx context.Context, attrs map[string]string)
+	// Extract returns a copy of ctx carrying the values read from attrs.
+	Extract(ctx context.Context, attrs map[string]string) context.Context
+}
+
+// WithPropagator makes Publish inject the values carried by its context into
+// the message attributes. Consumers restore them with MessageContext.
+func WithPropagator(p Propagator) func(*Client) {
+	return func(c *Client) {
+		c.propagator = p
+	}
+}
+
+// MessageContext returns a copy of ctx carrying the values the publisher
+// injected into msg, so work done while processing the message is linked to
+// the request that published it. It returns ctx unchanged when the client has
+// no propagator.
+//
+// Example:
+//
+//	for _, msg := range messages {
+//	    ctx := client.MessageContext(ctx, msg)
+//	    processMessage(ctx, msg)
+//	}
+func (c *Client) MessageContext(ctx context.Context, msg Message) context.Context {
+	if c.propagator == nil {
+		return ctx
+	}
+	return c.propagator.Extract(ctx, msg.Attributes)
+}
+
+// messageAttributes returns the attributes injected by the propagator in the
+// SQS message attribute format, or nil if there are none.
+func (c *Client) messageAttributes(ctx context.Context) map[string]types.MessageAttributeValue {
+	if c.propagator == nil {
+		return nil
+	}
+
+	attrs := make(map[string]string)
+	c.propagator.Inject(ctx, attrs)
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	values := make(map[string]types.MessageAttributeValue, len(attrs))
+	for k, v := range attrs {
+		values[k] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
+	}
+	return values
+}
+
+// stringAttributes returns the string message attributes of an SQS message.
+func stringAttributes(values map[string]types.MessageAttributeValue) map[string]string {
+	attrs := make(map[string]string, len(values))
+	for k, v := range values {
+		if v.StringValue != nil {
+			attrs[k] = *v.StringValue
+		}
+	}
+	return attrs
+}
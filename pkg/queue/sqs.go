@@ -8,17 +8,21 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
 type Client struct {
-	sqs      *sqs.Client
-	queueURL string
+	sqs        *sqs.Client
+	queueURL   string
+	observers  []Observer
+	propagator Propagator
 }
 
 type Message struct {
 	ID            string
 	Body          string
 	ReceiptHandle string
+	Attributes    map[string]string
 }
 
 type Config struct {
@@ -26,27 +30,37 @@ type Config struct {
 	QueueURL string
 }
 
-func NewClient(ctx context.Context, cfg Config) (*Client, error) {
+func NewClient(ctx context.Context, cfg Config, opts ...func(*Client)) (*Client, error) {
 	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
 	if err != nil {
 		return nil, fmt.Errorf("sqs: failed to load AWS config: %w", err)
 	}
 
-	return &Client{
+	c := Client{
 		sqs:      sqs.NewFromConfig(awsCfg),
 		queueURL: cfg.QueueURL,
-	}, nil
+	}
+
+	for _, fn := range opts {
+		fn(&c)
+	}
+
+	return &c, nil
 }
 
-func (c *Client) Publish(ctx context.Context, message any) error {
+func (c *Client) Publish(ctx context.Context, message any) (err error) {
+	ctx, finish := c.observe(ctx, OpPublish)
+	defer func() { finish(err) }()
+
 	b, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("sqs: failed to marshal JSON: %w", err)
 	}
 
 	input := &sqs.SendMessageInput{
-		QueueUrl:    aws.String(c.queueURL),
-		MessageBody: aws.String(string(b)),
+		QueueUrl:          aws.String(c.queueURL),
+		MessageBody:       aws.String(string(b)),
+		MessageAttributes: c.messageAttributes(ctx),
 	}
 
 	if _, err := c.sqs.SendMessage(ctx, input); err != nil {
@@ -56,11 +70,15 @@ func (c *Client) Publish(ctx context.Context, message any) error {
 	return nil
 }
 
-func (c *Client) Consume(ctx context.Context) ([]Message, error) {
+func (c *Client) Consume(ctx context.Context) (_ []Message, err error) {
+	ctx, finish := c.observe(ctx, OpConsume)
+	defer func() { finish(err) }()
+
 	input := &sqs.ReceiveMessageInput{
-		QueueUrl:            aws.String(c.queueURL),
-		MaxNumberOfMessages: *aws.Int32(10),
-		WaitTimeSeconds:     *aws.Int32(20),
+		QueueUrl:              aws.String(c.queueURL),
+		MaxNumberOfMessages:   *aws.Int32(10),
+		WaitTimeSeconds:       *aws.Int32(20),
+		MessageAttributeNames: []string{"All"},
 	}
 
 	result, err := c.sqs.ReceiveMessage(ctx, input)
@@ -74,6 +92,7 @@ func (c *Client) Consume(ctx context.Context) ([]Message, error) {
 			ID:            aws.ToString(msg.MessageId),
 			Body:          aws.ToString(msg.Body),
 			ReceiptHandle: aws.ToString(msg.ReceiptHandle),
+			Attributes:    stringAttributes(msg.MessageAttributes),
 		}
 		messages = append(messages, m)
 	}
@@ -81,16 +100,33 @@ func (c *Client) Consume(ctx context.Context) ([]Message, error) {
 	return messages, nil
 }
 
-func (c *Client) DeleteMessage(ctx context.Context, receiptHandle string) error {
+func (c *Client) DeleteMessage(ctx context.Context, receiptHandle string) (err error) {
+	ctx, finish := c.observe(ctx, OpDelete)
+	defer func() { finish(err) }()
+
 	input := &sqs.DeleteMessageInput{
 		QueueUrl:      aws.String(c.queueURL),
 		ReceiptHandle: aws.String(receiptHandle),
 	}
 
-	_, err := c.sqs.DeleteMessage(ctx, input)
-	if err != nil {
+	if _, err := c.sqs.DeleteMessage(ctx, input); err != nil {
 		return fmt.Errorf("sqs: failed to delete message: %w", err)
 	}
 
 	return nil
 }
+
+// Ping checks that the queue is reachable by reading its attributes. Its
+// signature matches server.Checker, so it can be registered as a health check.
+func (c *Client) Ping(ctx context.Context) error {
+	input := &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(c.queueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	}
+
+	if _, err := c.sqs.GetQueueAttributes(ctx, input); err != nil {
+		return fmt.Errorf("sqs: failed to ping queue: %w", err)
+	}
+
+	return nil
+}
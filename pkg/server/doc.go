@@ -19,6 +19,84 @@
 // SIGTERM, SIGQUIT), then gracefully shuts down allowing in-flight requests
 // to complete within the configured timeout.
 //
+// Run is the context-driven variant: it shuts down when ctx is cancelled and
+// installs no signal handler, so it can be driven by tests or an outer
+// orchestrator. Ready is closed once the listener is bound, and Addr then
+// reports the actual address, including the port picked when listening on :0:
+//
+//	srv := server.New(server.WithHandler(router), server.WithAddr("127.0.0.1:0"))
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	go srv.Run(ctx)
+//
+//	<-srv.Ready()
+//	resp, err := http.Get("http://" + srv.Addr() + "/users")
+//
+// WithListener serves on a pre-made net.Listener instead of binding the
+// configured address.
+//
+// TLS:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithPort(8443),
+//	    server.WithTLS("/etc/tls/tls.crt", "/etc/tls/tls.key"),
+//	    server.WithClientCA("/etc/tls/ca.crt"), // optional mutual TLS
+//	    server.WithHTTPRedirect(":8080"),        // optional HTTP -> HTTPS redirect
+//	)
+//
+// Certificate files are reloaded when they change, without restarting the
+// server. WithTLSConfig accepts a full tls.Config instead. With mutual TLS,
+// handlers read the client identity with PeerCertificate(r).
+//
+// HTTP/2 and HTTP/3:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithH2C(), // HTTP/2 over cleartext, e.g. behind a mesh
+//	    server.WithHTTP2MaxConcurrentStreams(500),
+//	    server.WithHTTP2MaxReadFrameSize(1<<20),
+//	)
+//
+// HTTPS servers negotiate HTTP/2 automatically. WithProtocol serves an
+// additional protocol alongside the main server; the separate http3 module
+// provides HTTP/3 over QUIC on the same UDP port, advertised with Alt-Svc:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithTLS(certFile, keyFile),
+//	    server.WithProtocol(http3.New()),
+//	)
+//
+// Zero-downtime restarts:
+//
+//	srv := server.New(server.WithHandler(router), server.WithGracefulRestart())
+//
+// With WithGracefulRestart, SIGHUP re-executes the binary and passes it the
+// listening socket using the systemd socket activation protocol (LISTEN_FDS),
+// waits for the new process to report it is serving, then drains the old
+// process within the shutdown timeout. Sockets passed by systemd socket
+// activation are picked up automatically on startup, each by the server
+// configured with its address.
+//
+// Draining:
+//
+// The server tracks in-flight requests; ActiveRequests and InFlight report
+// them. When shutdown begins, keep-alives are disabled, responses carry
+// Connection: close, and the channel returned by Draining is closed so
+// long-lived handlers (SSE, WebSockets) can finish cleanly:
+//
+//	select {
+//	case <-server.Draining(r.Context()):
+//	    // tell the client to reconnect and return
+//	case event := <-events:
+//	    // ...
+//	}
+//
+// Requests still running when the shutdown timeout is hit are logged with
+// their method, matched route and duration through the logger set with WithLogger.
+//
 // Custom configuration:
 //
 //	srv := server.New(
@@ -29,6 +107,67 @@
 //	    server.WithShutdownTimeout(60*time.Second),
 //	)
 //
+// Health checks:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithHealthCheck("postgres", db.PingContext),
+//	    server.WithHealthCheck("redis", cacheClient.Ping, server.WithCheckTimeout(time.Second)),
+//	    server.WithHealthCheck("sqs", queueClient.Ping, server.WithCheckCacheTTL(30*time.Second)),
+//	    server.WithShutdownDelay(5*time.Second),
+//	)
+//
+// Registering a check mounts three endpoints in front of the handler:
+//
+//	GET /livez   always 200 while the process is running
+//	GET /readyz  runs all checks; 503 if any fails or shutdown has begun
+//	GET /healthz runs all checks and reports them, ignoring shutdown state
+//
+// Reports are JSON with the aggregated status and per-check latency:
+//
+//	{"status":"ok","checks":[{"name":"postgres","status":"ok","latency_ms":1.2}]}
+//
+// Checks run concurrently, each bounded by its own timeout. Use
+// WithCheckCacheTTL for expensive checks so probes reuse the last result.
+// WithHealthEndpoints mounts the endpoints without registering any check.
+//
+// Lifecycle hooks run before the server starts listening and after it has
+// drained, each optionally bounded by its own timeout:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithOnStart("cache warmup", warmCache, server.WithHookTimeout(10*time.Second)),
+//	    server.WithOnShutdown("database", func(ctx context.Context) error { return db.Close() }),
+//	)
+//
+// Start hooks run in registration order; shutdown hooks run in reverse order
+// within the shutdown timeout.
+//
+// A Group runs several components in one process, such as a public API, an
+// admin server and a queue consumer. When one fails or a signal is received,
+// every component is shut down:
+//
+//	group := server.NewGroup(
+//	    server.WithComponent(api),
+//	    server.WithComponent(admin),
+//	    server.WithComponent(server.Func(consumer.Run)),
+//	)
+//	err := group.Run(ctx)
+//
+// Any type with Serve(ctx) and Shutdown(ctx) methods is a Component; Func
+// adapts a function that runs until its context is cancelled.
+//
+// Middleware given to WithMiddleware wraps the handler. WithMetricsHandler
+// serves GET /metrics and WithOpenAPIHandler serves GET /openapi.json and
+// /openapi.yaml next to the health endpoints:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithMiddleware(m.HTTPMiddleware()),
+//	    server.WithMetricsHandler(m.Handler()),
+//	    server.WithOpenAPIHandler(doc.Handler()),
+//	)
+//
 // WithHandler accepts any http.Handler, so it works with chi, gorilla, stdlib mux,
 // or any custom router.
 package server
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/httputil"
+)
+
+type drainKey struct{}
+
+// InFlightRequest describes a request that is being served. Route is the
+// matched pattern, e.g. "/users/{id}", or "" if it is not known yet.
+type InFlightRequest struct {
+	Method    string
+	Route     string
+	StartedAt time.Time
+}
+
+// trackedRequest is an in-flight request whose route is resolved when it
+// is read, since it is only known once the router has matched it. req is a
+// copy of the served request that handlers never see, so reading it does
+// not race with the router setting Pattern.
+type trackedRequest struct {
+	req       *http.Request
+	startedAt time.Time
+}
+
+// tracker keeps track of in-flight requests and signals handlers when the
+// server starts draining.
+type tracker struct {
+	mu       sync.Mutex
+	nextID   uint64
+	requests map[uint64]trackedRequest
+
+	draining  chan struct{}
+	drainOnce sync.Once
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		requests: make(map[uint64]trackedRequest),
+		draining: make(chan struct{}),
+	}
+}
+
+// Draining returns a channel that is closed when the server serving the
+// request starts shutting down. Long-lived handlers such as Server-Sent
+// Events streams and WebSockets should select on it to finish cleanly before
+// the shutdown timeout, since Shutdown does not wait for hijacked connections
+// and cancels nothing on its own. It returns nil, which blocks forever, when
+// ctx does not come from a request served by a Server.
+//
+// Example:
+//
+//	for {
+//	    select {
+//	    case <-r.Context().Done():
+//	        return
+//	    case <-server.Draining(r.Context()):
+//	        fmt.Fprint(w, "event: shutdown\ndata: reconnect\n\n")
+//	        return
+//	    case event := <-events:
+//	        writeEvent(w, event)
+//	    }
+//	}
+func Draining(ctx context.Context) <-chan struct{} {
+	ch, _ := ctx.Value(drainKey{}).(chan struct{})
+	return ch
+}
+
+// ActiveRequests returns the number of requests currently being served.
+func (s *Server) ActiveRequests() int {
+	s.tracker.mu.Lock()
+	defer s.tracker.mu.Unlock()
+	return len(s.tracker.requests)
+}
+
+// InFlight returns the requests currently being served.
+func (s *Server) InFlight() []InFlightRequest {
+	s.tracker.mu.Lock()
+	defer s.tracker.mu.Unlock()
+
+	requests := make([]InFlightRequest, 0, len(s.tracker.requests))
+	for _, t := range s.tracker.requests {
+		requests = append(requests, InFlightRequest{
+			Method:    t.req.Method,
+			Route:     httputil.Route(t.req),
+			StartedAt: t.startedAt,
+		})
+	}
+	return requests
+}
+
+// trackRequests records every request while it is served, exposes the drain
+// signal through the request context and asks clients to close their
+// connection once shutdown has begun.
+func (s *Server) trackRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), drainKey{}, s.tracker.draining)
+		r = httputil.TrackRoute(r.WithContext(ctx))
+
+		id := s.tracker.add(trackedRequest{req: r.WithContext(r.Context()), startedAt: time.Now()})
+		defer s.tracker.remove(id)
+
+		if s.shuttingDown.Load() && r.ProtoMajor == 1 {
+			w.Header().Set("Connection", "close")
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logInFlight reports the requests that did not complete before the
+// shutdown timeout.
+func (s *Server) logInFlight() {
+	for _, r := range s.InFlight() {
+		s.log.Warn("request still in flight at shutdown timeout",
+			"method", r.Method,
+			"route", r.Route,
+			"duration", time.Since(r.StartedAt).String(),
+		)
+	}
+}
+
+func (t *tracker) add(r trackedRequest) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nextID++
+	t.requests[t.nextID] = r
+	return t.nextID
+}
+
+func (t *tracker) remove(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.requests, id)
+}
+
+func (t *tracker) drain() {
+	t.drainOnce.Do(func() {
+		close(t.draining)
+	})
+}
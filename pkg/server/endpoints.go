@@ -0,0 +1,64 @@
+package server
+
+import "net/http"
+
+// WithMetricsHandler serves h on GET /metrics in front of the configured
+// handler, typically a Prometheus exposition handler.
+//
+// Example:
+//
+//	m, err := metrics.New()
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithMiddleware(m.HTTPMiddleware()),
+//	    server.WithMetricsHandler(m.Handler()),
+//	)
+func WithMetricsHandler(h http.Handler) func(*Server) {
+	return func(s *Server) {
+		s.metrics = h
+	}
+}
+
+// WithOpenAPIHandler serves h on GET /openapi.json and GET /openapi.yaml in
+// front of the configured handler, typically the handler of an
+// openapi.Document.
+//
+// Example:
+//
+//	srv := server.New(
+//	    server.WithHandler(mux),
+//	    server.WithOpenAPIHandler(doc.Handler()),
+//	)
+func WithOpenAPIHandler(h http.Handler) func(*Server) {
+	return func(s *Server) {
+		s.openapi = h
+	}
+}
+
+// endpointsHandler serves the health, metrics and OpenAPI endpoints and
+// passes every other request to next untouched, so the application's router
+// still sees unclean paths and sets r.Pattern itself.
+func (s *Server) endpointsHandler(next http.Handler) http.Handler {
+	endpoints := make(map[string]http.Handler)
+
+	if s.health != nil {
+		s.healthEndpoints(endpoints)
+	}
+	if s.metrics != nil {
+		endpoints["/metrics"] = s.metrics
+	}
+	if s.openapi != nil {
+		endpoints["/openapi.json"] = s.openapi
+		endpoints["/openapi.yaml"] = s.openapi
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			if h, ok := endpoints[r.URL.Path]; ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
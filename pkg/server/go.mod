@@ -1,3 +1,15 @@
 module github.com/bernardinorafael/gogem/pkg/server
 
 go 1.24.1
+
+require (
+	github.com/bernardinorafael/gogem/fault v0.1.0 // indirect
+	github.com/bernardinorafael/gogem/httputil v0.1.0
+	github.com/bernardinorafael/gogem/logger v0.1.0
+)
+
+replace (
+	github.com/bernardinorafael/gogem/fault => ../fault
+	github.com/bernardinorafael/gogem/httputil => ../httputil
+	github.com/bernardinorafael/gogem/logger => ../logger
+)
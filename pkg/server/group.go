@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Component is a long-running part of a process managed by a Group.
+// *Server implements Component.
+type Component interface {
+	// Serve runs the component and blocks until it stops. It returns nil
+	// when stopped by Shutdown and an error when it fails on its own.
+	Serve(ctx context.Context) error
+	// Shutdown gracefully stops the component within the deadline of ctx.
+	Shutdown(ctx context.Context) error
+}
+
+// Group runs several components together: they start together and when one
+// of them fails, or a shutdown signal is received, all of them are shut down.
+type Group struct {
+	components      []Component
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+}
+
+// NewGroup creates a Group. It uses the same defaults as Server: a 30s
+// shutdown timeout and SIGHUP, SIGINT, SIGTERM and SIGQUIT as shutdown signals.
+//
+// Example:
+//
+//	api := server.New(server.WithHandler(router), server.WithPort(8080))
+//	admin := server.New(server.WithHandler(adminRouter), server.WithPort(9090))
+//
+//	group := server.NewGroup(
+//	    server.WithComponent(api),
+//	    server.WithComponent(admin),
+//	    server.WithComponent(server.Func(consumer.Run)),
+//	)
+//
+//	if err := group.Run(context.Background()); err != nil {
+//	    log.Fatal("group error", "err", err)
+//	}
+func NewGroup(opts ...func(*Group)) *Group {
+	g := Group{
+		shutdownTimeout: 30 * time.Second,
+		signals:         []os.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT},
+	}
+
+	for _, fn := range opts {
+		fn(&g)
+	}
+
+	return &g
+}
+
+// WithComponent adds a component to the group. Components are shut down in
+// reverse order of registration.
+func WithComponent(c Component) func(*Group) {
+	return func(g *Group) {
+		g.components = append(g.components, c)
+	}
+}
+
+// WithGroupShutdownTimeout sets the total time allowed for all components
+// to shut down.
+func WithGroupShutdownTimeout(d time.Duration) func(*Group) {
+	return func(g *Group) {
+		g.shutdownTimeout = d
+	}
+}
+
+// WithSignals replaces the OS signals that trigger a shutdown. Passing no
+// signals disables signal handling, leaving ctx cancellation as the only
+// shutdown trigger.
+func WithSignals(signals ...os.Signal) func(*Group) {
+	return func(g *Group) {
+		g.signals = signals
+	}
+}
+
+// Run starts every component and blocks until ctx is cancelled, a shutdown
+// signal is received or a component fails. All components are then shut down
+// within the shutdown timeout. The returned error joins the failure that
+// triggered the shutdown, if any, with the shutdown errors.
+//
+// Sockets passed through socket activation are matched to the servers by
+// address, and there must be one per server without WithListener.
+func (g *Group) Run(ctx context.Context) error {
+	servers := 0
+	for _, c := range g.components {
+		if s, ok := c.(*Server); ok && s.listener == nil {
+			servers++
+		}
+	}
+	if err := checkInherited(servers); err != nil {
+		return err
+	}
+
+	if len(g.signals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, g.signals...)
+		defer stop()
+	}
+
+	serveCtx, cancelServe := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelServe()
+
+	errCh := make(chan error, len(g.components))
+	var wg sync.WaitGroup
+
+	for _, c := range g.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errCh <- c.Serve(serveCtx)
+		}()
+	}
+
+	var cause error
+	select {
+	case <-ctx.Done():
+	case cause = <-errCh:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), g.shutdownTimeout)
+	defer cancel()
+
+	errs := []error{cause}
+	for i := len(g.components) - 1; i >= 0; i-- {
+		if err := g.components[i].Shutdown(shutdownCtx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	cancelServe()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		// Some component ignored Shutdown; give up waiting for it.
+		return errors.Join(append(errs, shutdownCtx.Err())...)
+	}
+	close(errCh)
+
+	for err := range errCh {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Func adapts a function that blocks until its context is cancelled, such as
+// a queue consumer loop or a cron scheduler, into a Component. Shutdown
+// cancels the context and waits for the function to return.
+func Func(run func(ctx context.Context) error) Component {
+	return &funcComponent{run: run, done: make(chan struct{})}
+}
+
+type funcComponent struct {
+	run    func(ctx context.Context) error
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (f *funcComponent) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	f.mu.Lock()
+	f.cancel = cancel
+	f.mu.Unlock()
+
+	defer close(f.done)
+	defer cancel()
+
+	if err := f.run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+func (f *funcComponent) Shutdown(ctx context.Context) error {
+	f.mu.Lock()
+	cancel := f.cancel
+	f.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
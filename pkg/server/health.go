@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	healthStatusOK   = "ok"
+	healthStatusFail = "fail"
+
+	defaultCheckTimeout = 5 * time.Second
+)
+
+// Checker reports whether a dependency is healthy. Any function with this
+// signature can be registered, e.g. (*sqlx.DB).PingContext or
+// (*cache.Client).Ping.
+type Checker func(ctx context.Context) error
+
+type healthCheck struct {
+	name     string
+	check    Checker
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu        sync.Mutex
+	last      CheckResult
+	checkedAt time.Time
+}
+
+// CheckResult is the outcome of a single health check.
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Cached    bool    `json:"cached,omitempty"`
+}
+
+// HealthReport aggregates the results of all registered health checks.
+type HealthReport struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+type health struct {
+	checks []*healthCheck
+}
+
+// WithCheckTimeout sets how long a health check may run before it is
+// considered failed. Defaults to 5s.
+func WithCheckTimeout(d time.Duration) func(*healthCheck) {
+	return func(c *healthCheck) {
+		c.timeout = d
+	}
+}
+
+// WithCheckCacheTTL caches the result of a health check for the given
+// duration, so expensive checks are not run on every probe.
+func WithCheckCacheTTL(d time.Duration) func(*healthCheck) {
+	return func(c *healthCheck) {
+		c.cacheTTL = d
+	}
+}
+
+// WithHealthEndpoints mounts the /livez, /readyz and /healthz endpoints in
+// front of the configured handler. It is implied by WithHealthCheck.
+func WithHealthEndpoints() func(*Server) {
+	return func(s *Server) {
+		if s.health == nil {
+			s.health = &health{}
+		}
+	}
+}
+
+// WithHealthCheck registers a named health check and mounts the health
+// endpoints. Checks run concurrently on /readyz and /healthz.
+//
+// Example:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithHealthCheck("postgres", db.PingContext),
+//	    server.WithHealthCheck("redis", cacheClient.Ping, server.WithCheckTimeout(time.Second)),
+//	    server.WithHealthCheck("sqs", queueClient.Ping, server.WithCheckCacheTTL(30*time.Second)),
+//	)
+func WithHealthCheck(name string, check Checker, opts ...func(*healthCheck)) func(*Server) {
+	return func(s *Server) {
+		hc := &healthCheck{
+			name:    name,
+			check:   check,
+			timeout: defaultCheckTimeout,
+		}
+
+		for _, fn := range opts {
+			fn(hc)
+		}
+
+		WithHealthEndpoints()(s)
+		s.health.checks = append(s.health.checks, hc)
+	}
+}
+
+// WithShutdownDelay sets how long the server keeps serving after readiness
+// starts failing and before it stops accepting connections. This gives load
+// balancers time to observe the failing /readyz and stop routing traffic.
+func WithShutdownDelay(d time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.shutdownDelay = d
+	}
+}
+
+// Health runs every registered check and returns the aggregated report.
+func (s *Server) Health(ctx context.Context) HealthReport {
+	if s.health == nil {
+		return HealthReport{Status: healthStatusOK, Checks: []CheckResult{}}
+	}
+	return s.health.run(ctx)
+}
+
+// healthEndpoints adds the health endpoints to endpoints, keyed by path.
+func (s *Server) healthEndpoints(endpoints map[string]http.Handler) {
+	endpoints["/livez"] = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, HealthReport{Status: healthStatusOK, Checks: []CheckResult{}})
+	})
+
+	endpoints["/readyz"] = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.shuttingDown.Load() {
+			writeHealth(w, http.StatusServiceUnavailable, HealthReport{
+				Status: healthStatusFail,
+				Checks: []CheckResult{{Name: "shutdown", Status: healthStatusFail, Error: "server is shutting down"}},
+			})
+			return
+		}
+		report := s.health.run(r.Context())
+		writeHealth(w, reportStatusCode(report), report)
+	})
+
+	endpoints["/healthz"] = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := s.health.run(r.Context())
+		writeHealth(w, reportStatusCode(report), report)
+	})
+}
+
+func (h *health) run(ctx context.Context) HealthReport {
+	report := HealthReport{
+		Status: healthStatusOK,
+		Checks: make([]CheckResult, len(h.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, hc := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = hc.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != healthStatusOK {
+			report.Status = healthStatusFail
+			break
+		}
+	}
+
+	return report
+}
+
+// run returns the cached result when it is fresh, or runs the check. The
+// check runs in its own goroutine so a check ignoring its context still
+// fails once the timeout elapses; that goroutine is left to finish on its
+// own.
+func (hc *healthCheck) run(ctx context.Context) CheckResult {
+	hc.mu.Lock()
+	if hc.cacheTTL > 0 && !hc.checkedAt.IsZero() && time.Since(hc.checkedAt) < hc.cacheTTL {
+		result := hc.last
+		hc.mu.Unlock()
+		result.Cached = true
+		return result
+	}
+	hc.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, hc.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- hc.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	latency := time.Since(start)
+
+	result := CheckResult{
+		Name:      hc.name,
+		Status:    healthStatusOK,
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = healthStatusFail
+		result.Error = err.Error()
+	}
+
+	hc.mu.Lock()
+	hc.last = result
+	hc.checkedAt = time.Now()
+	hc.mu.Unlock()
+
+	return result
+}
+
+func reportStatusCode(report HealthReport) int {
+	if report.Status != healthStatusOK {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func writeHealth(w http.ResponseWriter, code int, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
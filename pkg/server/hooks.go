@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type hook struct {
+	name    string
+	fn      func(ctx context.Context) error
+	timeout time.Duration
+}
+
+// WithHookTimeout bounds how long a single hook may run. Shutdown hooks are
+// additionally bounded by the server's shutdown timeout.
+func WithHookTimeout(d time.Duration) func(*hook) {
+	return func(h *hook) {
+		h.timeout = d
+	}
+}
+
+// WithOnStart registers a hook that runs before the server starts listening.
+// Start hooks run in registration order; if one fails the server does not
+// start and Serve returns the hook's error.
+//
+// Example:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithOnStart("migrations", runMigrations, server.WithHookTimeout(time.Minute)),
+//	)
+func WithOnStart(name string, fn func(ctx context.Context) error, opts ...func(*hook)) func(*Server) {
+	return func(s *Server) {
+		s.onStart = append(s.onStart, newHook(name, fn, opts...))
+	}
+}
+
+// WithOnShutdown registers a hook that runs after the HTTP server stopped
+// accepting requests and in-flight requests completed. Shutdown hooks run in
+// reverse registration order, so resources opened first are released last.
+// Every hook runs even if a previous one failed.
+//
+// Example:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithOnShutdown("database", func(ctx context.Context) error {
+//	        return db.Close()
+//	    }),
+//	)
+func WithOnShutdown(name string, fn func(ctx context.Context) error, opts ...func(*hook)) func(*Server) {
+	return func(s *Server) {
+		s.onShutdown = append(s.onShutdown, newHook(name, fn, opts...))
+	}
+}
+
+func newHook(name string, fn func(ctx context.Context) error, opts ...func(*hook)) hook {
+	h := hook{name: name, fn: fn}
+	for _, opt := range opts {
+		opt(&h)
+	}
+	return h
+}
+
+func (h hook) run(ctx context.Context) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	if err := h.fn(ctx); err != nil {
+		return fmt.Errorf("hook %q: %w", h.name, err)
+	}
+	return nil
+}
+
+// runStartHooks runs the start hooks. Shutdown hooks wait for them, so a
+// shutdown requested during startup does not run both at the same time.
+func (s *Server) runStartHooks(ctx context.Context) error {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+
+	for _, h := range s.onStart {
+		if err := h.run(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) runShutdownHooks(ctx context.Context) error {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+
+	var errs []error
+	for i := len(s.onShutdown) - 1; i >= 0; i-- {
+		if err := s.onShutdown[i].run(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"net/http"
+)
+
+// Protocol is an additional server sharing the address, handler and TLS
+// configuration of the main server, such as the HTTP/3 server of package
+// http3. It keeps heavy protocol dependencies out of this package.
+type Protocol interface {
+	// Serve starts serving srv.Handler on addr in the background and returns
+	// the handler the main server uses instead, e.g. to advertise the
+	// protocol to clients. srv.TLSConfig is nil when TLS is not enabled.
+	Serve(addr string, srv *http.Server) (http.Handler, error)
+	// Shutdown gracefully stops the server started by Serve.
+	Shutdown(ctx context.Context) error
+}
+
+// WithH2C enables HTTP/2 over cleartext TCP (h2c) alongside HTTP/1.1, for
+// services running behind a proxy or service mesh that speaks h2c to its
+// upstreams. Only prior-knowledge h2c is supported; the deprecated
+// Upgrade: h2c handshake is not.
+func WithH2C() func(*Server) {
+	return func(s *Server) {
+		s.h2c = true
+	}
+}
+
+// WithHTTP2MaxConcurrentStreams sets the maximum number of concurrent
+// streams per HTTP/2 connection. Defaults to 250.
+func WithHTTP2MaxConcurrentStreams(n int) func(*Server) {
+	return func(s *Server) {
+		s.http2Config().MaxConcurrentStreams = n
+	}
+}
+
+// WithHTTP2MaxReadFrameSize sets the largest HTTP/2 frame the server is
+// willing to read. Valid values are between 16KiB and 16MiB; defaults to 1MiB.
+func WithHTTP2MaxReadFrameSize(n int) func(*Server) {
+	return func(s *Server) {
+		s.http2Config().MaxReadFrameSize = n
+	}
+}
+
+// WithProtocol additionally serves p alongside the main server, started
+// once the listener is bound and shut down along with it.
+//
+// Example:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithPort(443),
+//	    server.WithTLS(certFile, keyFile),
+//	    server.WithProtocol(http3.New()),
+//	)
+func WithProtocol(p Protocol) func(*Server) {
+	return func(s *Server) {
+		s.protocols = append(s.protocols, p)
+	}
+}
+
+func (s *Server) http2Config() *http.HTTP2Config {
+	if s.server.HTTP2 == nil {
+		s.server.HTTP2 = &http.HTTP2Config{}
+	}
+	return s.server.HTTP2
+}
+
+// setupProtocols enables the protocols selected through the options.
+func (s *Server) setupProtocols() {
+	if !s.h2c {
+		return
+	}
+
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	s.server.Protocols = &protocols
+}
+
+// startProtocols starts the additional protocol servers on the bound
+// address, each wrapping the handler of the main server.
+func (s *Server) startProtocols() error {
+	for _, p := range s.protocols {
+		handler, err := p.Serve(s.Addr(), s.server)
+		if err != nil {
+			return err
+		}
+		s.server.Handler = handler
+
+		s.mu.Lock()
+		s.started = append(s.started, p)
+		s.mu.Unlock()
+	}
+	return nil
+}
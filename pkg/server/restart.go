@@ -0,0 +1,265 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// listenFdsStart is the first file descriptor passed by systemd socket
+	// activation, after stdin, stdout and stderr.
+	listenFdsStart = 3
+
+	// readyFdEnv names the variable holding the file descriptor a process
+	// started by a graceful restart writes to once it is serving.
+	readyFdEnv = "SERVER_READY_FD"
+
+	defaultRestartTimeout = 30 * time.Second
+)
+
+// WithGracefulRestart makes SIGHUP restart the process without dropping
+// connections instead of shutting it down. The running binary is re-executed
+// with the listening socket passed as file descriptor 3, following the
+// systemd socket activation protocol (LISTEN_FDS). The old process keeps
+// serving until the new one reports it is accepting connections on the same
+// socket, then drains in-flight requests within the shutdown timeout and
+// exits. If the new process exits or is not ready within the restart timeout
+// (see WithRestartTimeout), it is killed and the old process keeps serving.
+//
+// It applies to ListenAndServe only; Run and Group leave signal handling to
+// the caller. Only the TCP listener is handed off.
+func WithGracefulRestart() func(*Server) {
+	return func(s *Server) {
+		s.gracefulRestart = true
+	}
+}
+
+// WithRestartTimeout sets how long a graceful restart waits for the new
+// process to start serving. Defaults to 30s.
+func WithRestartTimeout(d time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.restartTimeout = d
+	}
+}
+
+// inherited holds the listening sockets passed by systemd socket activation
+// or by a parent process restarting gracefully. They are read once per
+// process and claimed by the servers whose address they match.
+var inherited struct {
+	once      sync.Once
+	err       error
+	listeners []*inheritedListener
+
+	mu      sync.Mutex
+	servers int
+}
+
+type inheritedListener struct {
+	ln      net.Listener
+	claimed bool
+}
+
+// loadInherited reads the inherited sockets. The LISTEN_* variables are
+// cleared so the sockets are not leaked to child processes.
+func loadInherited() error {
+	inherited.once.Do(func() {
+		inherited.listeners, inherited.err = readInherited()
+	})
+	return inherited.err
+}
+
+func readInherited() ([]*inheritedListener, error) {
+	fds := os.Getenv("LISTEN_FDS")
+	if fds == "" {
+		return nil, nil
+	}
+
+	// systemd sets LISTEN_PID to the activated process; a gracefully
+	// restarting parent cannot know the child's PID and leaves it unset.
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("server: invalid LISTEN_FDS %q", fds)
+	}
+
+	listeners := make([]*inheritedListener, n)
+	for i := range n {
+		name := "listener"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(listenFdsStart+i), name)
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("server: failed to use inherited listener %q: %w", name, err)
+		}
+
+		listeners[i] = &inheritedListener{ln: ln}
+	}
+
+	return listeners, nil
+}
+
+// checkInherited returns an error when sockets were inherited and their
+// number differs from the n servers that will claim them, since the
+// remaining ones would be silently ignored or fresh ports bound instead.
+func checkInherited(n int) error {
+	if err := loadInherited(); err != nil {
+		return err
+	}
+	if got := len(inherited.listeners); got > 0 && got != n {
+		return fmt.Errorf("server: %d inherited listeners for %d servers", got, n)
+	}
+
+	inherited.mu.Lock()
+	inherited.servers = n
+	inherited.mu.Unlock()
+	return nil
+}
+
+// claimInherited returns the inherited listener bound to addr, or nil if no
+// sockets were inherited. A single socket passed to a single server is used
+// whatever its address. It is an error for a server to find no matching
+// socket.
+func claimInherited(addr string) (net.Listener, error) {
+	if err := loadInherited(); err != nil {
+		return nil, err
+	}
+	if len(inherited.listeners) == 0 {
+		return nil, nil
+	}
+
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+
+	single := len(inherited.listeners) == 1 && inherited.servers == 1
+	for _, l := range inherited.listeners {
+		if !l.claimed && (single || addrMatches(addr, l.ln.Addr())) {
+			l.claimed = true
+			return l.ln, nil
+		}
+	}
+	return nil, fmt.Errorf("server: no inherited listener for %s", addr)
+}
+
+// addrMatches reports whether a listener bound to got serves the configured
+// address addr: the ports must be equal, unless addr has port 0, and the
+// hosts too when addr names an IP.
+func addrMatches(addr string, got net.Addr) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	tcp, ok := got.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	if port != "0" && port != strconv.Itoa(tcp.Port) {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+		return ip.Equal(tcp.IP)
+	}
+	return true
+}
+
+// notifyReady tells the parent of a graceful restart, if any, that this
+// process is serving.
+var notifyReady = sync.OnceFunc(func() {
+	fd := os.Getenv(readyFdEnv)
+	if fd == "" {
+		return
+	}
+	_ = os.Unsetenv(readyFdEnv)
+
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return
+	}
+
+	f := os.NewFile(uintptr(n), "ready")
+	_, _ = f.Write([]byte{1})
+	_ = f.Close()
+})
+
+// restart starts a new instance of the running binary that inherits the
+// listening socket, and waits until it reports it is serving. The caller is
+// responsible for shutting down the current instance afterwards.
+func (s *Server) restart() error {
+	s.mu.Lock()
+	ln := s.listener
+	s.mu.Unlock()
+
+	fl, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return errors.New("server: listener does not support handoff")
+	}
+
+	f, err := fl.File()
+	if err != nil {
+		return fmt.Errorf("server: failed to get listener file: %w", err)
+	}
+	defer f.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("server: failed to find executable: %w", err)
+	}
+
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("server: failed to create readiness pipe: %w", err)
+	}
+	defer ready.Close()
+
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "LISTEN_") && !strings.HasPrefix(kv, readyFdEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, "LISTEN_FDS=1", readyFdEnv+"="+strconv.Itoa(listenFdsStart+1))
+
+	p, err := os.StartProcess(exe, os.Args, &os.ProcAttr{
+		Env:   env,
+		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr, f, readyW},
+	})
+	// Only the child holds the write end now, so reading hits EOF if it
+	// exits before it is ready.
+	_ = readyW.Close()
+	if err != nil {
+		return fmt.Errorf("server: failed to start new process: %w", err)
+	}
+
+	_ = ready.SetReadDeadline(time.Now().Add(s.restartTimeout))
+
+	if _, err := ready.Read(make([]byte, 1)); err != nil {
+		_ = p.Kill()
+		_, _ = p.Wait()
+		if errors.Is(err, io.EOF) {
+			return errors.New("server: new process exited before serving")
+		}
+		return fmt.Errorf("server: new process not ready: %w", err)
+	}
+
+	return p.Release()
+}
@@ -2,18 +2,53 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/httputil"
+	"github.com/bernardinorafael/gogem/pkg/logger"
 )
 
 type Server struct {
 	server          *http.Server
 	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	health          *health
+	shuttingDown    atomic.Bool
+	onStart         []hook
+	onShutdown      []hook
+	tracker         *tracker
+	middleware      []func(http.Handler) http.Handler
+	metrics         http.Handler
+	openapi         http.Handler
+
+	tlsConfig          *tls.Config
+	certReloader       *certReloader
+	certReloadInterval time.Duration
+	clientCAFile       string
+	redirectAddr       string
+	h2c                bool
+	protocols          []Protocol
+	gracefulRestart    bool
+	restartTimeout     time.Duration
+	log                logger.Logger
+
+	hooksMu sync.Mutex
+
+	mu       sync.Mutex
+	listener net.Listener
+	redirect *http.Server
+	started  []Protocol
+	ready    chan struct{}
 }
 
 func New(opts ...func(*Server)) *Server {
@@ -25,13 +60,32 @@ func New(opts ...func(*Server)) *Server {
 			WriteTimeout: 10 * time.Second,
 			Handler:      http.DefaultServeMux,
 		},
-		shutdownTimeout: 30 * time.Second,
+		shutdownTimeout:    30 * time.Second,
+		certReloadInterval: 10 * time.Second,
+		restartTimeout:     defaultRestartTimeout,
+		ready:              make(chan struct{}),
+		log:                logger.FromContext(context.Background()),
+		tracker:            newTracker(),
 	}
 
 	for _, fn := range opts {
 		fn(&srv)
 	}
 
+	srv.server.Handler = httputil.RecordRoute(srv.server.Handler)
+
+	for i := len(srv.middleware) - 1; i >= 0; i-- {
+		srv.server.Handler = srv.middleware[i](srv.server.Handler)
+	}
+
+	if srv.health != nil || srv.metrics != nil || srv.openapi != nil {
+		srv.server.Handler = srv.endpointsHandler(srv.server.Handler)
+	}
+
+	srv.server.Handler = srv.trackRequests(srv.server.Handler)
+
+	srv.setupProtocols()
+
 	return &srv
 }
 
@@ -41,6 +95,22 @@ func WithHandler(handler http.Handler) func(*Server) {
 	}
 }
 
+// WithMiddleware wraps the handler with mw. Middlewares run in the order they
+// are given, inside the health and metrics endpoints, so those are not
+// affected by them.
+//
+// Example:
+//
+//	srv := server.New(
+//	    server.WithHandler(mux),
+//	    server.WithMiddleware(m.HTTPMiddleware()),
+//	)
+func WithMiddleware(mw func(http.Handler) http.Handler) func(*Server) {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, mw)
+	}
+}
+
 func WithPort(port int) func(*Server) {
 	return func(s *Server) {
 		s.server.Addr = fmt.Sprintf(":%d", port)
@@ -53,6 +123,23 @@ func WithAddr(addr string) func(*Server) {
 	}
 }
 
+// WithListener makes the server accept connections on a pre-made listener
+// instead of listening on the configured address. This is useful in tests
+// and when the socket is created by a supervisor.
+func WithListener(l net.Listener) func(*Server) {
+	return func(s *Server) {
+		s.listener = l
+	}
+}
+
+// WithLogger sets the logger used for server lifecycle events.
+// Defaults to charmbracelet/log's default logger.
+func WithLogger(l logger.Logger) func(*Server) {
+	return func(s *Server) {
+		s.log = l
+	}
+}
+
 func WithReadTimeout(d time.Duration) func(*Server) {
 	return func(s *Server) {
 		s.server.ReadTimeout = d
@@ -81,29 +168,256 @@ func WithShutdownTimeout(d time.Duration) func(*Server) {
 // is received (SIGHUP, SIGINT, SIGTERM, SIGQUIT). It then gracefully shuts
 // down the server, allowing in-flight requests to complete within the
 // configured shutdown timeout.
+//
+// As soon as a signal is received /readyz starts failing. The server keeps
+// accepting connections for the configured shutdown delay before it stops
+// listening.
+//
+// With WithGracefulRestart, SIGHUP hands the listening socket over to a new
+// process before shutting down instead.
 func (s *Server) ListenAndServe() error {
-	errCh := make(chan error, 1)
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}
+	if !s.gracefulRestart {
+		signals = append(signals, syscall.SIGHUP)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+
+	if !s.gracefulRestart {
+		return s.Run(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
 
 	go func() {
-		if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-			errCh <- err
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if err := s.restart(); err != nil {
+					// Keep serving; dropping the only running instance is worse
+					// than skipping a restart.
+					s.log.Error("graceful restart failed", "err", err)
+					continue
+				}
+				s.log.Info("graceful restart: new process started, draining")
+				cancel()
+				return
+			}
 		}
 	}()
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	return s.Run(ctx)
+}
+
+// Run serves HTTP until ctx is cancelled, then gracefully shuts down the
+// server within the configured shutdown timeout. It does not handle OS
+// signals, so it can be stopped from tests or an outer orchestrator. When
+// sockets are passed through socket activation there must be exactly one;
+// use a Group to serve several.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	go srv.Run(ctx)
+//
+//	<-srv.Ready()
+//	resp, err := http.Get("http://" + srv.Addr() + "/users")
+//	cancel()
+func (s *Server) Run(ctx context.Context) error {
+	if s.listener == nil {
+		if err := checkInherited(1); err != nil {
+			return err
+		}
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- s.Serve(ctx)
+	}()
 
 	select {
 	case err := <-errCh:
 		return err
-	case <-stop:
-		ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.shutdownTimeout)
 		defer cancel()
-		return s.server.Shutdown(ctx)
+		err := s.Shutdown(shutdownCtx)
+
+		// Serve returns once the server is closed, or, when cancelled
+		// during startup, once the start hooks are done.
+		select {
+		case serveErr := <-errCh:
+			return errors.Join(err, serveErr)
+		case <-shutdownCtx.Done():
+			return errors.Join(err, shutdownCtx.Err())
+		}
 	}
 }
 
-// Addr returns the server's listen address.
+// Serve runs the start hooks and serves HTTP until Shutdown is called, in
+// which case it returns nil. Unlike ListenAndServe it does not handle OS
+// signals, which makes it suitable for use inside a Group.
+func (s *Server) Serve(ctx context.Context) error {
+	if err := s.runStartHooks(ctx); err != nil {
+		return err
+	}
+
+	// Shut down while the start hooks ran: do not start listening.
+	if s.shuttingDown.Load() {
+		return nil
+	}
+
+	useTLS, err := s.setupTLS()
+	if err != nil {
+		return err
+	}
+
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	if err := s.startProtocols(); err != nil {
+		_ = ln.Close()
+		return err
+	}
+
+	if useTLS {
+		if err := s.startRedirect(); err != nil {
+			_ = ln.Close()
+			return err
+		}
+		err = s.server.ServeTLS(ln, "", "")
+	} else {
+		err = s.server.Serve(ln)
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// startRedirect binds the HTTP-to-HTTPS redirect listener, if configured,
+// and serves it in the background until Shutdown.
+func (s *Server) startRedirect() error {
+	redirect := s.redirectServer()
+	if redirect == nil {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", redirect.Addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.redirect = redirect
+	s.mu.Unlock()
+
+	go func() {
+		_ = redirect.Serve(ln)
+	}()
+
+	return nil
+}
+
+// Ready returns a channel that is closed once the server is bound to its
+// listener and accepting connections.
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// listen binds the configured address, unless a listener was provided with
+// WithListener or inherited through socket activation for that address, and
+// signals readiness, including to the parent of a graceful restart.
+func (s *Server) listen() (net.Listener, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		ln, err := claimInherited(s.server.Addr)
+		if err != nil {
+			return nil, err
+		}
+		s.listener = ln
+	}
+
+	if s.listener == nil {
+		ln, err := net.Listen("tcp", s.server.Addr)
+		if err != nil {
+			return nil, err
+		}
+		s.listener = ln
+	}
+
+	select {
+	case <-s.ready:
+	default:
+		close(s.ready)
+		notifyReady()
+	}
+
+	return s.listener, nil
+}
+
+// Shutdown marks the server as shutting down so /readyz fails, notifies
+// long-lived handlers through Draining, waits for the shutdown delay, stops
+// accepting connections, waits for in-flight requests and finally runs the
+// shutdown hooks, all within the deadline of ctx. Responses sent while
+// draining carry Connection: close. Requests still in flight when the
+// deadline is hit are logged.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+	s.server.SetKeepAlivesEnabled(false)
+	s.tracker.drain()
+
+	s.log.Info("shutting down server", "addr", s.Addr(), "active_requests", s.ActiveRequests())
+
+	select {
+	case <-time.After(s.shutdownDelay):
+	case <-ctx.Done():
+	}
+
+	err := s.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.logInFlight()
+	}
+
+	s.mu.Lock()
+	redirect, started := s.redirect, s.started
+	s.mu.Unlock()
+
+	if redirect != nil {
+		err = errors.Join(err, redirect.Shutdown(ctx))
+	}
+	for _, p := range started {
+		err = errors.Join(err, p.Shutdown(ctx))
+	}
+
+	return errors.Join(err, s.runShutdownHooks(ctx))
+}
+
+// Addr returns the address the server is bound to. Before the server starts
+// listening it returns the configured address; afterwards it returns the
+// listener's actual address, which includes the port chosen by the OS when
+// listening on port 0.
 func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
 	return s.server.Addr
 }
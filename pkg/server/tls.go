@@ -0,0 +1,225 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithTLS serves HTTPS using the certificate and key at the given paths.
+// The files are checked for changes at most once every reload interval
+// (10s by default, see WithCertReloadInterval) and reloaded without
+// restarting the server, so rotated certificates are picked up
+// automatically. If a reload fails the previous certificate keeps being served.
+//
+// Example:
+//
+//	srv := server.New(
+//	    server.WithHandler(router),
+//	    server.WithPort(8443),
+//	    server.WithTLS("/etc/tls/tls.crt", "/etc/tls/tls.key"),
+//	)
+func WithTLS(certFile, keyFile string) func(*Server) {
+	return func(s *Server) {
+		s.certReloader = &certReloader{
+			certFile: certFile,
+			keyFile:  keyFile,
+		}
+	}
+}
+
+// WithCertReloadInterval sets how often the files given to WithTLS are
+// checked for changes.
+func WithCertReloadInterval(d time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.certReloadInterval = d
+	}
+}
+
+// WithTLSConfig serves HTTPS using the given TLS configuration. When combined
+// with WithTLS, the certificate from the files replaces cfg.Certificates and
+// cfg.GetCertificate, and is served for every handshake.
+func WithTLSConfig(cfg *tls.Config) func(*Server) {
+	return func(s *Server) {
+		s.tlsConfig = cfg.Clone()
+	}
+}
+
+// WithClientCA enables mutual TLS: clients must present a certificate signed
+// by one of the CAs in the PEM file at caFile. Handlers can read the verified
+// client certificate with PeerCertificate.
+func WithClientCA(caFile string) func(*Server) {
+	return func(s *Server) {
+		s.clientCAFile = caFile
+	}
+}
+
+// WithHTTPRedirect starts an additional plain HTTP listener on addr that
+// redirects every request to the HTTPS server with 308 Permanent Redirect.
+// It has no effect unless TLS is enabled.
+func WithHTTPRedirect(addr string) func(*Server) {
+	return func(s *Server) {
+		s.redirectAddr = addr
+	}
+}
+
+// PeerCertificate returns the verified client certificate of a mutual TLS
+// connection, or nil if the request was not made over TLS or the client
+// presented no certificate.
+//
+// Example:
+//
+//	if cert := server.PeerCertificate(r); cert != nil {
+//	    serviceName := cert.Subject.CommonName
+//	}
+func PeerCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// setupTLS builds the server's TLS configuration from the TLS options. It
+// returns false when TLS is not enabled.
+func (s *Server) setupTLS() (bool, error) {
+	if s.tlsConfig == nil && s.certReloader == nil {
+		return false, nil
+	}
+
+	cfg := s.tlsConfig
+	if cfg == nil {
+		cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if s.certReloader != nil {
+		s.certReloader.interval = s.certReloadInterval
+		if err := s.certReloader.load(); err != nil {
+			return false, err
+		}
+		// crypto/tls serves Certificates[0] instead of calling
+		// GetCertificate when the client sends no SNI.
+		cfg.Certificates = nil
+		cfg.GetCertificate = s.certReloader.getCertificate
+	}
+
+	if s.clientCAFile != "" {
+		pem, err := os.ReadFile(s.clientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("server: failed to read client CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return false, errors.New("server: no valid certificates in client CA file")
+		}
+
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	s.server.TLSConfig = cfg
+	return true, nil
+}
+
+// redirectServer returns the HTTP server that redirects to HTTPS, or nil if
+// no redirect listener is configured.
+func (s *Server) redirectServer() *http.Server {
+	if s.redirectAddr == "" {
+		return nil
+	}
+
+	return &http.Server{
+		Addr:              s.redirectAddr,
+		ReadHeaderTimeout: s.server.ReadTimeout,
+		IdleTimeout:       s.server.IdleTimeout,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host := r.Host
+			if h, _, err := net.SplitHostPort(r.Host); err == nil {
+				host = h
+			}
+
+			if _, port, err := net.SplitHostPort(s.Addr()); err == nil && port != "443" {
+				host = net.JoinHostPort(host, port)
+			}
+
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+		}),
+	}
+}
+
+// certReloader serves a certificate loaded from disk and reloads it when the
+// certificate or key file changes.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	cert, checkedAt := c.cert, c.checkedAt
+	c.mu.RUnlock()
+
+	if time.Since(checkedAt) >= c.interval {
+		// Keep serving the previous certificate if the new one is invalid.
+		_ = c.load()
+
+		c.mu.RLock()
+		cert = c.cert
+		c.mu.RUnlock()
+	}
+
+	return cert, nil
+}
+
+// load reads the certificate and key if either file changed since the last
+// successful load.
+func (c *certReloader) load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkedAt = time.Now()
+
+	modTime, err := latestModTime(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("server: failed to stat certificate: %w", err)
+	}
+
+	if c.cert != nil && !modTime.After(c.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("server: failed to load certificate: %w", err)
+	}
+
+	c.cert = &cert
+	c.modTime = modTime
+	return nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
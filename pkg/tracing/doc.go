@@ -0,0 +1,46 @@
+// Package tracing provides OpenTelemetry instrumentation for HTTP requests
+// and for the cache, queue and dbutil packages.
+//
+// Creating the instrumentation and tracing incoming requests:
+//
+//	t := tracing.New(tracing.WithTracerProvider(tp))
+//
+//	srv := server.New(
+//	    server.WithHandler(mux),
+//	    server.WithMiddleware(t.HTTPMiddleware()),
+//	)
+//
+// HTTPMiddleware continues the trace from the W3C traceparent header and
+// records a server span per request named after its method and route.
+//
+// Instrumenting the other packages through their observers:
+//
+//	cacheClient := cache.New(rdb, log, cache.WithObserver(t.CacheObserver()))
+//	dbutil.RegisterObserver(t.DBObserver())
+//	queueClient, err := queue.NewClient(ctx, cfg,
+//	    queue.WithObserver(t.QueueObserver()),
+//	    queue.WithPropagator(t.QueuePropagator()),
+//	)
+//
+// With the propagator, Publish writes the trace context to the message
+// attributes and consumers continue it with queueClient.MessageContext.
+//
+// Failed operations record the error on their span and use its fault tag as
+// the status description and the fault.tag attribute. Faults written with
+// httputil.WriteError are recorded on the server span the same way.
+//
+// New registers LogFields with the logger package, so every logger returned
+// by logger.FromContext carries the trace_id and span_id of the active span.
+//
+// # Testing
+//
+// Spans can be inspected in tests with the SDK's in-memory exporter:
+//
+//	exporter := tracetest.NewInMemoryExporter()
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+//	t := tracing.New(tracing.WithTracerProvider(tp))
+//
+//	// exercise the code under test
+//
+//	spans := exporter.GetSpans()
+package tracing
@@ -0,0 +1,27 @@
+module github.com/bernardinorafael/gogem/pkg/tracing
+
+go 1.24.1
+
+require (
+	github.com/bernardinorafael/gogem/fault v0.1.0
+	github.com/bernardinorafael/gogem/httputil v0.1.0
+	github.com/bernardinorafael/gogem/logger v0.1.0
+	github.com/bernardinorafael/gogem/queue v0.1.0
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/sdk v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+)
+
+replace (
+	github.com/bernardinorafael/gogem/fault => ../fault
+	github.com/bernardinorafael/gogem/httputil => ../httputil
+	github.com/bernardinorafael/gogem/logger => ../logger
+	github.com/bernardinorafael/gogem/queue => ../queue
+)
@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+	"github.com/bernardinorafael/gogem/pkg/httputil"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type httpConfig struct {
+	route func(*http.Request) string
+}
+
+// WithRouteFunc sets how the route is derived from a request. It is called
+// after the handler returns. Defaults to httputil.Route, the pattern matched
+// by the standard library ServeMux.
+//
+// Example with chi:
+//
+//	t.HTTPMiddleware(tracing.WithRouteFunc(func(r *http.Request) string {
+//	    return chi.RouteContext(r.Context()).RoutePattern()
+//	}))
+func WithRouteFunc(fn func(*http.Request) string) func(*httpConfig) {
+	return func(c *httpConfig) {
+		c.route = fn
+	}
+}
+
+// HTTPMiddleware returns a middleware that continues the trace described by
+// the W3C traceparent and tracestate headers, or starts a new one, and
+// records a server span for each request named after its method and route.
+//
+// Responses with a 5xx status mark the span as failed. When the handler
+// writes a fault with httputil.WriteError, its tag is recorded on the span
+// and used as the status description.
+func (t *Tracing) HTTPMiddleware(opts ...func(*httpConfig)) func(http.Handler) http.Handler {
+	cfg := httpConfig{
+		route: httputil.Route,
+	}
+
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			scheme := "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+
+			ctx, span := t.tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+					semconv.URLScheme(scheme),
+					semconv.UserAgentOriginal(r.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			sw := &spanWriter{ResponseWriter: w, status: http.StatusOK}
+			req := httputil.TrackRoute(r.WithContext(ctx))
+
+			next.ServeHTTP(sw, req)
+
+			if route := cfg.route(req); route != "" {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(sw.status))
+
+			switch {
+			case sw.err != nil && sw.status >= http.StatusInternalServerError:
+				recordError(span, sw.err)
+			case sw.err != nil:
+				// Client errors do not mark server spans as failed.
+				span.SetAttributes(faultTagKey.String(string(fault.GetTag(sw.err))))
+			case sw.status >= http.StatusInternalServerError:
+				span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+		})
+	}
+}
+
+// spanWriter captures the status code and the error written by the handler.
+type spanWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	err         error
+}
+
+// RecordError implements httputil.ErrorRecorder.
+func (sw *spanWriter) RecordError(err error) {
+	if sw.err == nil {
+		sw.err = err
+	}
+}
+
+func (sw *spanWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.status = code
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *spanWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(b)
+}
+
+func (sw *spanWriter) Flush() {
+	_ = http.NewResponseController(sw.ResponseWriter).Flush()
+}
+
+func (sw *spanWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+func (sw *spanWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(sw.ResponseWriter).Hijack()
+}
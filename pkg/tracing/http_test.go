@@ -0,0 +1,167 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+	"github.com/bernardinorafael/gogem/pkg/httputil"
+	"github.com/bernardinorafael/gogem/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		handler     http.HandlerFunc
+		wantName    string
+		wantRoute   string
+		wantStatus  int
+		wantCode    codes.Code
+		wantDesc    string
+		wantFault   string
+		wantErrType string
+	}{
+		{
+			name:       "matched route",
+			path:       "/users/42",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) },
+			wantName:   "GET /users/{id}",
+			wantRoute:  "/users/{id}",
+			wantStatus: http.StatusOK,
+			wantCode:   codes.Unset,
+		},
+		{
+			name: "client fault",
+			path: "/users/42",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				httputil.WriteError(w, fault.NewNotFound("user not found"))
+			},
+			wantName:   "GET /users/{id}",
+			wantRoute:  "/users/{id}",
+			wantStatus: http.StatusNotFound,
+			wantCode:   codes.Unset,
+			wantFault:  string(fault.NotFound),
+		},
+		{
+			name: "server fault",
+			path: "/users/42",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				httputil.WriteError(w, fault.NewInternalServerError("boom"))
+			},
+			wantName:    "GET /users/{id}",
+			wantRoute:   "/users/{id}",
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    codes.Error,
+			wantDesc:    string(fault.InternalServerError),
+			wantFault:   string(fault.InternalServerError),
+			wantErrType: string(fault.InternalServerError),
+		},
+		{
+			name:       "unmatched route",
+			path:       "/unknown",
+			wantName:   "GET",
+			wantStatus: http.StatusNotFound,
+			wantCode:   codes.Unset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			tr := tracing.New(tracing.WithTracerProvider(tp))
+
+			mux := http.NewServeMux()
+			if tt.handler != nil {
+				mux.HandleFunc("GET /users/{id}", tt.handler)
+			}
+
+			// The inner middleware copies the request, as timeouts and
+			// authentication middleware do, so the pattern set by the mux is
+			// only visible through httputil.RecordRoute.
+			copyRequest := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(w, r.WithContext(r.Context()))
+				})
+			}
+			handler := tr.HTTPMiddleware()(copyRequest(httputil.RecordRoute(mux)))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+
+			if span.Name != tt.wantName {
+				t.Errorf("span name = %q, want %q", span.Name, tt.wantName)
+			}
+			if span.SpanKind != trace.SpanKindServer {
+				t.Errorf("span kind = %v, want server", span.SpanKind)
+			}
+			if span.Status.Code != tt.wantCode || span.Status.Description != tt.wantDesc {
+				t.Errorf("span status = %v %q, want %v %q", span.Status.Code, span.Status.Description, tt.wantCode, tt.wantDesc)
+			}
+
+			attrs := attributes(span.Attributes)
+			if got := attrs["http.response.status_code"].AsInt64(); got != int64(tt.wantStatus) {
+				t.Errorf("http.response.status_code = %d, want %d", got, tt.wantStatus)
+			}
+			if got := attrs["http.route"].AsString(); got != tt.wantRoute {
+				t.Errorf("http.route = %q, want %q", got, tt.wantRoute)
+			}
+			if got := attrs["http.request.method"].AsString(); got != http.MethodGet {
+				t.Errorf("http.request.method = %q, want GET", got)
+			}
+			if got := attrs["url.path"].AsString(); got != tt.path {
+				t.Errorf("url.path = %q, want %q", got, tt.path)
+			}
+			if got := attrs["fault.tag"].AsString(); got != tt.wantFault {
+				t.Errorf("fault.tag = %q, want %q", got, tt.wantFault)
+			}
+			if got := attrs["error.type"].AsString(); got != tt.wantErrType {
+				t.Errorf("error.type = %q, want %q", got, tt.wantErrType)
+			}
+		})
+	}
+}
+
+func TestHTTPMiddlewareContinuesTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tr := tracing.New(tracing.WithTracerProvider(tp))
+
+	handler := tr.HTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the one from traceparent", got)
+	}
+	if got := spans[0].Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span ID = %s, want the one from traceparent", got)
+	}
+}
+
+func attributes(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
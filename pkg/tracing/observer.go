@@ -0,0 +1,138 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/bernardinorafael/gogem/pkg/queue"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	cacheKeyKey     = attribute.Key("cache.key")
+	cacheOutcomeKey = attribute.Key("cache.outcome")
+	txOutcomeKey    = attribute.Key("db.transaction.outcome")
+)
+
+// CacheObserver returns an observer for cache.WithObserver creating a span
+// for every cache operation. Misses are recorded as an outcome, not as
+// errors.
+func (t *Tracing) CacheObserver() *CacheObserver {
+	return &CacheObserver{t: t}
+}
+
+// QueueObserver returns an observer for queue.WithObserver creating a span
+// for every publish, consume and delete call. Combine it with
+// QueuePropagator so consumers continue the publisher's trace.
+func (t *Tracing) QueueObserver() *QueueObserver {
+	return &QueueObserver{t: t}
+}
+
+// QueuePropagator returns a propagator for queue.WithPropagator carrying the
+// trace context in SQS message attributes.
+func (t *Tracing) QueuePropagator() *QueuePropagator {
+	return &QueuePropagator{t: t}
+}
+
+// DBObserver returns an observer for dbutil.RegisterObserver creating a span
+// for every transaction run by ExecTx.
+func (t *Tracing) DBObserver() *DBObserver {
+	return &DBObserver{t: t}
+}
+
+// CacheObserver implements cache.Observer.
+type CacheObserver struct {
+	t *Tracing
+}
+
+// Start implements cache.Observer.
+func (o *CacheObserver) Start(ctx context.Context, op, key string) (context.Context, func(outcome string, err error)) {
+	ctx, span := o.t.tracer.Start(ctx, "cache "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameRedis,
+			semconv.DBOperationName(op),
+			cacheKeyKey.String(key),
+		),
+	)
+
+	return ctx, func(outcome string, err error) {
+		span.SetAttributes(cacheOutcomeKey.String(outcome))
+		if err != nil {
+			recordError(span, err)
+		}
+		span.End()
+	}
+}
+
+// QueueObserver implements queue.Observer.
+type QueueObserver struct {
+	t *Tracing
+}
+
+// Start implements queue.Observer.
+func (o *QueueObserver) Start(ctx context.Context, op string) (context.Context, func(err error)) {
+	kind := trace.SpanKindClient
+	opType := semconv.MessagingOperationTypeReceive
+	switch op {
+	case queue.OpPublish:
+		kind = trace.SpanKindProducer
+		opType = semconv.MessagingOperationTypeSend
+	case queue.OpDelete:
+		opType = semconv.MessagingOperationTypeSettle
+	}
+
+	ctx, span := o.t.tracer.Start(ctx, "queue "+op,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			semconv.MessagingSystemAWSSQS,
+			semconv.MessagingOperationName(op),
+			opType,
+		),
+	)
+
+	return ctx, func(err error) {
+		if err != nil {
+			recordError(span, err)
+		}
+		span.End()
+	}
+}
+
+// QueuePropagator implements queue.Propagator.
+type QueuePropagator struct {
+	t *Tracing
+}
+
+// Inject implements queue.Propagator.
+func (p *QueuePropagator) Inject(ctx context.Context, attrs map[string]string) {
+	p.t.propagator.Inject(ctx, propagation.MapCarrier(attrs))
+}
+
+// Extract implements queue.Propagator.
+func (p *QueuePropagator) Extract(ctx context.Context, attrs map[string]string) context.Context {
+	return p.t.propagator.Extract(ctx, propagation.MapCarrier(attrs))
+}
+
+// DBObserver implements dbutil.Observer.
+type DBObserver struct {
+	t *Tracing
+}
+
+// Start implements dbutil.Observer.
+func (o *DBObserver) Start(ctx context.Context) (context.Context, func(outcome string, err error)) {
+	ctx, span := o.t.tracer.Start(ctx, "db transaction",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL),
+	)
+
+	return ctx, func(outcome string, err error) {
+		span.SetAttributes(txOutcomeKey.String(outcome))
+		if err != nil {
+			recordError(span, err)
+		}
+		span.End()
+	}
+}
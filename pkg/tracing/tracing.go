@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+	"github.com/bernardinorafael/gogem/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/bernardinorafael/gogem/pkg/tracing"
+
+// faultTagKey records the fault tag of the error that ended a span.
+const faultTagKey = attribute.Key("fault.tag")
+
+var registerLogFields sync.Once
+
+// Tracing creates spans for HTTP requests and for the cache, queue and
+// dbutil packages.
+type Tracing struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	tracer     trace.Tracer
+}
+
+// WithTracerProvider sets the provider spans are created with. Defaults to
+// the global provider set with otel.SetTracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) func(*Tracing) {
+	return func(t *Tracing) {
+		t.provider = tp
+	}
+}
+
+// WithPropagator sets how trace context is read from incoming requests and
+// written to published messages. Defaults to W3C Trace Context and Baggage.
+func WithPropagator(p propagation.TextMapPropagator) func(*Tracing) {
+	return func(t *Tracing) {
+		t.propagator = p
+	}
+}
+
+// New creates the instrumentation. The first call also registers LogFields
+// with logger.RegisterContextFields, so loggers returned by
+// logger.FromContext carry the trace_id and span_id of the active span.
+//
+// Example:
+//
+//	t := tracing.New(tracing.WithTracerProvider(tp))
+//	cacheClient := cache.New(rdb, log, cache.WithObserver(t.CacheObserver()))
+//	dbutil.RegisterObserver(t.DBObserver())
+func New(opts ...func(*Tracing)) *Tracing {
+	t := Tracing{
+		provider: otel.GetTracerProvider(),
+		propagator: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	}
+
+	for _, fn := range opts {
+		fn(&t)
+	}
+
+	t.tracer = t.provider.Tracer(instrumentationName)
+
+	registerLogFields.Do(func() {
+		logger.RegisterContextFields(LogFields)
+	})
+
+	return &t
+}
+
+// LogFields returns the trace_id and span_id of the span in ctx as logger
+// key-value pairs, or nil if ctx carries no valid span.
+func LogFields(ctx context.Context) []any {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []any{"trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()}
+}
+
+// recordError records err on span and marks the span as failed with the
+// fault tag of err as the status description.
+func recordError(span trace.Span, err error) {
+	tag := string(fault.GetTag(err))
+
+	span.RecordError(err)
+	span.SetAttributes(faultTagKey.String(tag), semconv.ErrorTypeKey.String(tag))
+	span.SetStatus(codes.Error, tag)
+}
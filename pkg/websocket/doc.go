@@ -0,0 +1,56 @@
+// Package websocket provides WebSocket connections exchanging JSON messages,
+// with fault-aware error frames, keepalive, and a hub for broadcasting to
+// groups of connections.
+//
+// Upgrading a request and reading messages:
+//
+//	func notifications(w http.ResponseWriter, r *http.Request) {
+//	    conn, err := websocket.Upgrade(w, r,
+//	        websocket.WithOriginPatterns("app.example.com"),
+//	        websocket.WithReadLimit(64<<10),
+//	    )
+//	    if err != nil {
+//	        return // the HTTP error response has been written
+//	    }
+//	    defer conn.Close()
+//
+//	    for {
+//	        var msg Subscribe
+//	        if err := conn.ReadJSON(&msg); err != nil {
+//	            if fault.GetTag(err) == fault.BadRequest {
+//	                _ = conn.WriteError(err)
+//	                continue
+//	            }
+//	            return
+//	        }
+//	        hub.Join(msg.Channel, conn)
+//	    }
+//	}
+//
+// Only same-origin connections are accepted unless WithOriginPatterns is
+// given. Messages larger than the read limit (32KiB by default) close the
+// connection. WriteError sends errors in the fault.Fault JSON shape used by
+// httputil.WriteError.
+//
+// The server pings every connection (every 30s by default, see
+// WithPingInterval) and drops those that stop answering. Pongs are only
+// processed while the connection is read, so connections that only send
+// messages must call CloseRead.
+//
+// Each connection has a context, returned by Context, that is cancelled when
+// the connection closes. When the connection was upgraded from a request
+// served by server.Server, it is also closed with status 1001 (going away)
+// as soon as the server starts shutting down.
+//
+// # Hub
+//
+// A Hub broadcasts messages to groups of connections:
+//
+//	hub := websocket.NewHub()
+//
+//	hub.Join("user:"+userID, conn)
+//	err := hub.Broadcast(ctx, "user:"+userID, Notification{Text: "order shipped"})
+//
+// Broadcast encodes the message once and writes it to every connection
+// concurrently. Connections leave their groups when they close.
+package websocket
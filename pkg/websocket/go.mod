@@ -0,0 +1,14 @@
+module github.com/bernardinorafael/gogem/pkg/websocket
+
+go 1.24.1
+
+require (
+	github.com/bernardinorafael/gogem/fault v0.1.0
+	github.com/bernardinorafael/gogem/server v0.1.0
+	github.com/coder/websocket v1.8.14
+)
+
+replace (
+	github.com/bernardinorafael/gogem/fault => ../fault
+	github.com/bernardinorafael/gogem/server => ../server
+)
@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+)
+
+// Hub tracks connections by group, such as a chat room or the connections
+// of a user, and broadcasts messages to every connection of a group.
+// Connections leave their groups automatically when they close.
+type Hub struct {
+	mu     sync.RWMutex
+	groups map[string]map[*Conn]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		groups: make(map[string]map[*Conn]struct{}),
+	}
+}
+
+// Join adds c to group. Joining a group twice has no effect.
+func (h *Hub) Join(group string, c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	members, ok := h.groups[group]
+	if !ok {
+		members = make(map[*Conn]struct{})
+		h.groups[group] = members
+	}
+	if _, joined := members[c]; joined {
+		return
+	}
+	members[c] = struct{}{}
+
+	go func() {
+		<-c.Context().Done()
+		h.Leave(group, c)
+	}()
+}
+
+// Leave removes c from group.
+func (h *Hub) Leave(group string, c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	members := h.groups[group]
+	delete(members, c)
+	if len(members) == 0 {
+		delete(h.groups, group)
+	}
+}
+
+// Count returns the number of connections in group.
+func (h *Hub) Count(group string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.groups[group])
+}
+
+// Broadcast sends v as JSON to every connection in group. The message is
+// encoded once and written to the connections concurrently; connections
+// that fail to receive it are closed and leave the hub. It returns once
+// every write has finished or ctx is done.
+//
+// Example:
+//
+//	err := hub.Broadcast(ctx, "order:"+order.ID, OrderUpdated{Status: order.Status})
+func (h *Hub) Broadcast(ctx context.Context, group string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fault.NewInternalServerError("failed to marshal message", fault.WithErr(err))
+	}
+
+	h.mu.RLock()
+	members := make([]*Conn, 0, len(h.groups[group]))
+	for c := range h.groups[group] {
+		members = append(members, c)
+	}
+	h.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, c := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A failed write cancels the connection's context, which makes
+			// it leave its groups.
+			_ = c.write(data)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
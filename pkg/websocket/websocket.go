@@ -0,0 +1,264 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bernardinorafael/gogem/pkg/fault"
+	"github.com/bernardinorafael/gogem/pkg/server"
+	"github.com/coder/websocket"
+)
+
+type config struct {
+	originPatterns []string
+	subprotocols   []string
+	readLimit      int64
+	pingInterval   time.Duration
+	writeTimeout   time.Duration
+}
+
+// WithOriginPatterns allows cross-origin connections from hosts matching
+// the given patterns, e.g. "app.example.com" or "*.example.com". By default
+// only same-origin connections are accepted and any other origin is
+// rejected with 403 Forbidden.
+func WithOriginPatterns(patterns ...string) func(*config) {
+	return func(c *config) {
+		c.originPatterns = append(c.originPatterns, patterns...)
+	}
+}
+
+// WithSubprotocols sets the subprotocols the server negotiates, in order of
+// preference.
+func WithSubprotocols(protocols ...string) func(*config) {
+	return func(c *config) {
+		c.subprotocols = append(c.subprotocols, protocols...)
+	}
+}
+
+// WithReadLimit sets the maximum size in bytes of a message read from the
+// client. Larger messages close the connection with status 1009 (message
+// too big). Defaults to 32KiB.
+func WithReadLimit(n int64) func(*config) {
+	return func(c *config) {
+		c.readLimit = n
+	}
+}
+
+// WithPingInterval sets how often the server pings the client. A client
+// that does not answer before the next ping is disconnected. Defaults to
+// 30s; zero disables keepalive.
+func WithPingInterval(d time.Duration) func(*config) {
+	return func(c *config) {
+		c.pingInterval = d
+	}
+}
+
+// WithWriteTimeout sets how long a single write may take before the
+// connection is considered dead. Defaults to 10s.
+func WithWriteTimeout(d time.Duration) func(*config) {
+	return func(c *config) {
+		c.writeTimeout = d
+	}
+}
+
+// Conn is a WebSocket connection exchanging JSON messages.
+type Conn struct {
+	conn         *websocket.Conn
+	ctx          context.Context
+	cancel       context.CancelFunc
+	writeTimeout time.Duration
+}
+
+// Upgrade upgrades the HTTP connection to the WebSocket protocol. On
+// failure the HTTP error response has already been written.
+//
+// The connection's context is cancelled when the connection closes or when
+// the server serving the request starts shutting down, in which case the
+// connection is closed with status 1001 (going away) so clients reconnect
+// to another instance.
+//
+// Example:
+//
+//	func notifications(w http.ResponseWriter, r *http.Request) {
+//	    conn, err := websocket.Upgrade(w, r, websocket.WithOriginPatterns("app.example.com"))
+//	    if err != nil {
+//	        return
+//	    }
+//	    defer conn.Close()
+//
+//	    for {
+//	        var msg Subscribe
+//	        if err := conn.ReadJSON(&msg); err != nil {
+//	            if fault.GetTag(err) == fault.BadRequest {
+//	                _ = conn.WriteError(err)
+//	                continue
+//	            }
+//	            return
+//	        }
+//	        hub.Join(msg.Channel, conn)
+//	    }
+//	}
+func Upgrade(w http.ResponseWriter, r *http.Request, opts ...func(*config)) (*Conn, error) {
+	cfg := config{
+		readLimit:    32 << 10,
+		pingInterval: 30 * time.Second,
+		writeTimeout: 10 * time.Second,
+	}
+
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: cfg.originPatterns,
+		Subprotocols:   cfg.subprotocols,
+	})
+	if err != nil {
+		return nil, fault.New("failed to upgrade connection", fault.WithErr(err))
+	}
+	conn.SetReadLimit(cfg.readLimit)
+
+	// The connection outlives the handler's write deadline once hijacked,
+	// so it gets its own context detached from the request.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+
+	c := &Conn{
+		conn:         conn,
+		ctx:          ctx,
+		cancel:       cancel,
+		writeTimeout: cfg.writeTimeout,
+	}
+
+	go c.watchShutdown(server.Draining(r.Context()))
+	if cfg.pingInterval > 0 {
+		go c.keepalive(cfg.pingInterval)
+	}
+
+	return c, nil
+}
+
+// Context returns the connection's context, cancelled when the connection
+// closes or the server shuts down.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Subprotocol returns the negotiated subprotocol, or an empty string.
+func (c *Conn) Subprotocol() string {
+	return c.conn.Subprotocol()
+}
+
+// ReadJSON reads the next message and decodes it into v. Messages that are
+// not valid JSON for v are reported as a BadRequest fault and leave the
+// connection usable, so the caller can answer with WriteError and keep
+// reading. Any other error means the connection is closed.
+func (c *Conn) ReadJSON(v any) error {
+	typ, data, err := c.conn.Read(c.ctx)
+	if err != nil {
+		c.cancel()
+		return err
+	}
+
+	if typ != websocket.MessageText {
+		return fault.NewBadRequest("message must be a JSON text message")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fault.NewBadRequest("message contains badly-formed JSON", fault.WithErr(err))
+	}
+
+	return nil
+}
+
+// WriteJSON encodes v as JSON and sends it as a text message.
+func (c *Conn) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fault.NewInternalServerError("failed to marshal message", fault.WithErr(err))
+	}
+	return c.write(data)
+}
+
+// WriteError sends err to the client as a fault.Fault JSON message, in the
+// same shape httputil.WriteError uses for HTTP responses. Errors that are
+// not faults are sent as a generic internal server error.
+func (c *Conn) WriteError(err error) error {
+	var f *fault.Fault
+	if !errors.As(err, &f) {
+		f = fault.NewInternalServerError("an unexpected error occurred")
+	}
+	return c.WriteJSON(f)
+}
+
+// CloseRead discards incoming messages in the background while still
+// answering pings and close frames. Use it on connections that only send
+// messages, such as notification streams; a data message from the client
+// closes the connection.
+func (c *Conn) CloseRead() {
+	c.conn.CloseRead(c.ctx)
+}
+
+// Close closes the connection with status 1000 (normal closure).
+func (c *Conn) Close() error {
+	return c.CloseWithStatus(int(websocket.StatusNormalClosure), "")
+}
+
+// CloseWithStatus closes the connection with the given close status code,
+// such as 4000-4999 for application-defined statuses, and reason.
+func (c *Conn) CloseWithStatus(code int, reason string) error {
+	defer c.cancel()
+
+	err := c.conn.Close(websocket.StatusCode(code), reason)
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (c *Conn) write(data []byte) error {
+	ctx, cancel := context.WithTimeout(c.ctx, c.writeTimeout)
+	defer cancel()
+
+	if err := c.conn.Write(ctx, websocket.MessageText, data); err != nil {
+		c.cancel()
+		return err
+	}
+	return nil
+}
+
+// watchShutdown closes the connection when the server starts draining.
+func (c *Conn) watchShutdown(draining <-chan struct{}) {
+	select {
+	case <-c.ctx.Done():
+	case <-draining:
+		_ = c.CloseWithStatus(int(websocket.StatusGoingAway), "server shutting down")
+	}
+}
+
+// keepalive pings the client every interval and drops the connection when
+// a pong is not received in time. Pongs are only processed while the
+// connection is being read, through ReadJSON or CloseRead.
+func (c *Conn) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(c.ctx, interval)
+			err := c.conn.Ping(ctx)
+			cancel()
+
+			if err != nil {
+				_ = c.conn.CloseNow()
+				c.cancel()
+				return
+			}
+		}
+	}
+}